@@ -0,0 +1,272 @@
+package bili
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/config"
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+)
+
+// 开放平台接口路径
+const (
+	PathAppStart     = "/v2/app/start"
+	PathAppHeartbeat = "/v2/app/heartbeat"
+	PathAppEnd       = "/v2/app/end"
+)
+
+// 开放平台错误码
+const (
+	CodeOK              = 0
+	CodeParamInvalid    = 4000 // 参数错误
+	CodeAppInvalid      = 4001 // 应用无效
+	CodeSignInvalid     = 4002 // 签名异常
+	CodeRequestExpired  = 4003 // 请求过期
+	CodeRequestRepeated = 4004 // 重复请求
+	CodeRequestCooling  = 7001 // 请求冷却期
+	CodeGameRepeated    = 7002 // 房间重复游戏
+	CodeGameExpired     = 7003 // 心跳过期或场次不存在
+	CodeRoomCodeInvalid = 7007 // 身份码错误
+)
+
+// APIError 开放平台返回的业务错误，可以用 errors.Is 与 ErrXxx 比较错误码
+type APIError struct {
+	Code      int64  // 错误码
+	Message   string // 错误信息
+	RequestID string // 请求id，用于向平台反馈问题
+	Path      string // 接口路径
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("开放平台接口 %s 返回错误: Code=%d, Message=%s, RequestId=%s", e.Path, e.Code, e.Message, e.RequestID)
+}
+
+// Is 错误码相同即视为同一错误
+func (e *APIError) Is(target error) bool {
+	t, ok := target.(*APIError)
+	return ok && t.Code == e.Code
+}
+
+// 已知错误码对应的错误，用于 errors.Is 判断
+var (
+	ErrParamInvalid    = &APIError{Code: CodeParamInvalid, Message: "参数错误"}
+	ErrAppInvalid      = &APIError{Code: CodeAppInvalid, Message: "应用无效"}
+	ErrSignInvalid     = &APIError{Code: CodeSignInvalid, Message: "签名异常"}
+	ErrRequestExpired  = &APIError{Code: CodeRequestExpired, Message: "请求过期"}
+	ErrRequestRepeated = &APIError{Code: CodeRequestRepeated, Message: "重复请求"}
+	ErrRequestCooling  = &APIError{Code: CodeRequestCooling, Message: "请求冷却期"}
+	ErrGameRepeated    = &APIError{Code: CodeGameRepeated, Message: "房间重复游戏"}
+	ErrGameExpired     = &APIError{Code: CodeGameExpired, Message: "心跳过期或场次不存在"}
+	ErrRoomCodeInvalid = &APIError{Code: CodeRoomCodeInvalid, Message: "身份码错误"}
+)
+
+// ClientOptions 开放平台接口客户端配置
+type ClientOptions struct {
+	BaseURL        string        // 接口地址，为空时使用 config.GetOpenPlatformHttpHost()
+	AccessKey      string        // Access Key，为空时从 .env 读取
+	SecretKey      string        // Access Key Secret，为空时从 .env 读取
+	HTTPClient     *http.Client  // 为空时使用10秒超时的客户端
+	MaxRetries     int           // 临时错误的最大重试次数，默认2，小于0时不重试
+	RetryBaseDelay time.Duration // 重试基础等待时间，按指数退避增长，默认500毫秒
+}
+
+// Client 开放平台接口客户端，负责签名、超时和临时错误重试
+type Client struct {
+	opts ClientOptions
+}
+
+// NewClient 创建开放平台接口客户端
+func NewClient(opts ClientOptions) *Client {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = 2
+	}
+	if opts.RetryBaseDelay <= 0 {
+		opts.RetryBaseDelay = 500 * time.Millisecond
+	}
+	return &Client{opts: opts}
+}
+
+// StartApp 开启场次
+func (c *Client) StartApp(ctx context.Context, code string, appID int64) (*StartAppRespData, error) {
+	data := &StartAppRespData{}
+	if err := c.Do(ctx, PathAppStart, StartAppRequest{Code: code, AppId: appID}, data); err != nil {
+		return nil, err
+	}
+	if data.GameInfo.GameId == "" {
+		return nil, fmt.Errorf("StartApp API返回的场次id为空")
+	}
+	return data, nil
+}
+
+// AppHeartbeat 发送场次心跳
+func (c *Client) AppHeartbeat(ctx context.Context, gameID string) error {
+	return c.Do(ctx, PathAppHeartbeat, AppHeartbeatReq{GameId: gameID}, nil)
+}
+
+// EndApp 关闭场次
+func (c *Client) EndApp(ctx context.Context, gameID string, appID int64) error {
+	return c.Do(ctx, PathAppEnd, EndAppRequest{GameId: gameID, AppId: appID}, nil)
+}
+
+// Do 发送请求，返回码不为0时返回 *APIError，data 不为空时解析响应数据
+// 网络错误、服务端5xx和请求冷却期会按指数退避重试，开启场次只在请求冷却期重试
+func (c *Client) Do(ctx context.Context, path string, req any, data any) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("序列化请求参数失败: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, path, body)
+		if err == nil && resp.Code != CodeOK {
+			err = &APIError{Code: resp.Code, Message: resp.Message, RequestID: resp.RequestId, Path: path}
+		}
+		if err == nil {
+			if data != nil && len(resp.Data) > 0 {
+				if err := json.Unmarshal(resp.Data, data); err != nil {
+					return fmt.Errorf("解析 %s 响应数据失败: %w", path, err)
+				}
+			}
+			return nil
+		}
+
+		lastErr = err
+		if attempt >= c.opts.MaxRetries || ctx.Err() != nil || !retryable(path, err) {
+			return lastErr
+		}
+
+		delay := time.Duration(float64(c.opts.RetryBaseDelay) * math.Pow(2, float64(attempt)))
+		logger.Warn(fmt.Sprintf("请求 %s 失败，%v 后重试 (%d/%d): %v", path, delay, attempt+1, c.opts.MaxRetries, err))
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (重试已取消: %v)", lastErr, ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+// send 签名并发送一次请求，每次发送都会生成新的时间戳和随机数
+func (c *Client) send(ctx context.Context, path string, body []byte) (BaseResp, error) {
+	resp := BaseResp{}
+
+	nonce, err := newNonce()
+	if err != nil {
+		return resp, err
+	}
+	header := &CommonHeader{
+		ContentType:       JsonType,
+		ContentAcceptType: JsonType,
+		Timestamp:         strconv.FormatInt(time.Now().Unix(), 10),
+		SignatureMethod:   HmacSha256,
+		SignatureVersion:  BiliVersion,
+		Nonce:             nonce,
+		AccessKeyId:       c.accessKey(),
+		ContentMD5:        Md5(string(body)),
+	}
+	header.Authorization = CreateSignature(header, c.secretKey())
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL()+path, bytes.NewReader(body))
+	if err != nil {
+		return resp, fmt.Errorf("创建请求失败: %w", err)
+	}
+	for k, v := range header.ToMap() {
+		httpReq.Header.Set(k, v)
+	}
+
+	httpResp, err := c.opts.HTTPClient.Do(httpReq)
+	if err != nil {
+		return resp, &transportError{err: err}
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return resp, &transportError{err: err}
+	}
+	if httpResp.StatusCode >= http.StatusInternalServerError || httpResp.StatusCode == http.StatusTooManyRequests {
+		return resp, &transportError{err: fmt.Errorf("HTTP状态码 %d", httpResp.StatusCode)}
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return resp, fmt.Errorf("解析响应失败 (HTTP状态码 %d): %w", httpResp.StatusCode, err)
+	}
+	return resp, nil
+}
+
+// baseURL 获取接口地址
+func (c *Client) baseURL() string {
+	if c.opts.BaseURL != "" {
+		return c.opts.BaseURL
+	}
+	return config.GetOpenPlatformHttpHost()
+}
+
+// accessKey 获取Access Key
+func (c *Client) accessKey() string {
+	if c.opts.AccessKey != "" {
+		return c.opts.AccessKey
+	}
+	return GetAccessKey()
+}
+
+// secretKey 获取Access Key Secret
+func (c *Client) secretKey() string {
+	if c.opts.SecretKey != "" {
+		return c.opts.SecretKey
+	}
+	return GetAccessKeySecret()
+}
+
+// transportError 网络层错误或服务端异常，可以重试
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string {
+	return fmt.Sprintf("请求开放平台失败: %v", e.err)
+}
+
+func (e *transportError) Unwrap() error {
+	return e.err
+}
+
+// isTransient 是否为可以重试的临时错误
+func isTransient(err error) bool {
+	var te *transportError
+	if errors.As(err, &te) {
+		return true
+	}
+	return errors.Is(err, ErrRequestCooling)
+}
+
+// retryable 请求失败后是否可以重试
+// 开启场次不是幂等的：响应丢失时平台可能已经开启了场次，重试会得到 7002 且拿不到场次id，
+// 所以只在平台明确拒绝（请求冷却期）时重试
+func retryable(path string, err error) bool {
+	if path == PathAppStart {
+		return errors.Is(err, ErrRequestCooling)
+	}
+	return isTransient(err)
+}
+
+// newNonce 生成签名使用的随机数
+func newNonce() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
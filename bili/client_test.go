@@ -0,0 +1,49 @@
+package bili
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		call      func(c *Client) error
+		wantCalls int32
+	}{
+		{
+			// 开启场次不是幂等的，网络错误时不重试
+			name:      "开启场次",
+			call:      func(c *Client) error { _, err := c.StartApp(context.Background(), "CODE", 1); return err },
+			wantCalls: 1,
+		},
+		{
+			name:      "心跳",
+			call:      func(c *Client) error { return c.AppHeartbeat(context.Background(), "game") },
+			wantCalls: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				w.WriteHeader(http.StatusBadGateway)
+			}))
+			defer srv.Close()
+
+			c := NewClient(ClientOptions{BaseURL: srv.URL, AccessKey: "ak", SecretKey: "sk", RetryBaseDelay: time.Millisecond})
+			if err := tt.call(c); err == nil {
+				t.Fatal("期望请求失败")
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("请求次数 = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
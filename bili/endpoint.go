@@ -0,0 +1,127 @@
+package bili
+
+import (
+	"sync"
+	"time"
+)
+
+// endpointFailureWindow 统计节点失败次数的时间窗口，窗口外的失败不再影响选择
+const endpointFailureWindow = 5 * time.Minute
+
+// EndpointHealth 单个长连节点的健康状况
+type EndpointHealth struct {
+	Addr           string    // 长连地址
+	RecentFailures int       // 时间窗口内的失败次数
+	LastFailure    time.Time // 最近一次失败时间
+	LastSuccess    time.Time // 最近一次连接成功时间
+}
+
+// endpointPool 管理StartApp返回的所有长连节点，按最近失败次数选择节点
+type endpointPool struct {
+	mu       sync.Mutex
+	addrs    []string
+	failures map[string][]time.Time
+	success  map[string]time.Time
+	current  int
+	now      func() time.Time // 当前时间，测试时可以替换
+}
+
+func newEndpointPool(addrs []string) *endpointPool {
+	return &endpointPool{
+		addrs:    addrs,
+		failures: make(map[string][]time.Time),
+		success:  make(map[string]time.Time),
+		current:  -1,
+		now:      time.Now,
+	}
+}
+
+// next 选择最近失败次数最少的节点，次数相同时从当前节点之后轮换
+func (ep *endpointPool) next() string {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+
+	if len(ep.addrs) == 0 {
+		return ""
+	}
+
+	now := ep.now()
+	best, bestCount := -1, 0
+	for i := 1; i <= len(ep.addrs); i++ {
+		idx := (ep.current + i) % len(ep.addrs)
+		if idx < 0 {
+			idx += len(ep.addrs)
+		}
+		count := ep.recentFailuresLocked(ep.addrs[idx], now)
+		if best == -1 || count < bestCount {
+			best, bestCount = idx, count
+		}
+	}
+	ep.current = best
+	return ep.addrs[best]
+}
+
+// active 返回当前使用的节点
+func (ep *endpointPool) active() string {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+
+	if ep.current < 0 || ep.current >= len(ep.addrs) {
+		return ""
+	}
+	return ep.addrs[ep.current]
+}
+
+// markFailure 记录节点的一次失败
+func (ep *endpointPool) markFailure(addr string) {
+	if addr == "" {
+		return
+	}
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	ep.failures[addr] = append(ep.failures[addr], ep.now())
+}
+
+// markSuccess 记录节点的一次成功连接
+func (ep *endpointPool) markSuccess(addr string) {
+	if addr == "" {
+		return
+	}
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	ep.success[addr] = ep.now()
+}
+
+// snapshot 返回所有节点的健康状况
+func (ep *endpointPool) snapshot() []EndpointHealth {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+
+	now := ep.now()
+	result := make([]EndpointHealth, 0, len(ep.addrs))
+	for _, addr := range ep.addrs {
+		h := EndpointHealth{
+			Addr:           addr,
+			RecentFailures: ep.recentFailuresLocked(addr, now),
+			LastSuccess:    ep.success[addr],
+		}
+		if f := ep.failures[addr]; len(f) > 0 {
+			h.LastFailure = f[len(f)-1]
+		}
+		result = append(result, h)
+	}
+	return result
+}
+
+// recentFailuresLocked 清理窗口外的失败记录并返回窗口内的次数（调用前需要加锁）
+func (ep *endpointPool) recentFailuresLocked(addr string, now time.Time) int {
+	failures := ep.failures[addr]
+	kept := failures[:0]
+	for _, t := range failures {
+		if now.Sub(t) <= endpointFailureWindow {
+			kept = append(kept, t)
+		}
+	}
+	ep.failures[addr] = kept
+	return len(kept)
+}
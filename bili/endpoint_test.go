@@ -0,0 +1,101 @@
+package bili
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestPool 创建使用假时钟的节点池，返回的函数用于拨动时钟
+func newTestPool(addrs ...string) (*endpointPool, func(d time.Duration)) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ep := newEndpointPool(addrs)
+	ep.now = func() time.Time { return now }
+	return ep, func(d time.Duration) { now = now.Add(d) }
+}
+
+// nextN 连续选择n次节点
+func nextN(ep *endpointPool, n int) []string {
+	var got []string
+	for range n {
+		got = append(got, ep.next())
+	}
+	return got
+}
+
+func TestEndpointPoolRoundRobin(t *testing.T) {
+	ep, _ := newTestPool("a", "b", "c")
+
+	got := nextN(ep, 4)
+	want := []string{"a", "b", "c", "a"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("选择顺序 = %v, want %v", got, want)
+		}
+	}
+	if ep.active() != "a" {
+		t.Errorf("active() = %q, want a", ep.active())
+	}
+}
+
+func TestEndpointPoolSkipsFailed(t *testing.T) {
+	ep, advance := newTestPool("a", "b", "c")
+
+	ep.markFailure("a")
+	ep.markFailure("b")
+	for _, addr := range nextN(ep, 3) {
+		if addr != "c" {
+			t.Fatalf("a、b失败后选择了 %s, want c", addr)
+		}
+	}
+
+	// 失败次数少的节点优先
+	ep.markFailure("c")
+	ep.markFailure("c")
+	if got := ep.next(); got != "a" {
+		t.Errorf("c失败两次后 next() = %s, want a", got)
+	}
+
+	// 窗口过后失败记录不再影响选择
+	advance(endpointFailureWindow + time.Second)
+	for _, h := range ep.snapshot() {
+		if h.RecentFailures != 0 {
+			t.Errorf("窗口过后 %s 的失败次数 = %d, want 0", h.Addr, h.RecentFailures)
+		}
+	}
+	got := nextN(ep, 3)
+	want := []string{"b", "c", "a"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("窗口过后选择顺序 = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestEndpointPoolAllFailed(t *testing.T) {
+	ep, advance := newTestPool("a", "b", "c")
+
+	for _, addr := range []string{"a", "b", "c"} {
+		ep.markFailure(addr)
+		advance(time.Second)
+	}
+
+	// 所有节点失败次数相同时继续轮换
+	got := nextN(ep, 4)
+	want := []string{"a", "b", "c", "a"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("全部失败时选择顺序 = %v, want %v", got, want)
+		}
+	}
+
+	// 成功连接不清除窗口内的失败记录
+	ep.markSuccess("a")
+	for _, h := range ep.snapshot() {
+		if h.RecentFailures != 1 {
+			t.Errorf("%s 的失败次数 = %d, want 1", h.Addr, h.RecentFailures)
+		}
+		if h.Addr == "a" && h.LastSuccess.IsZero() {
+			t.Error("a 的最近成功时间为空")
+		}
+	}
+}
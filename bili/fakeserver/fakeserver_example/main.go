@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/bili"
+	"github.com/CoffeeSwt/bilibili-tts-chat/bili/fakeserver"
+	"github.com/CoffeeSwt/bilibili-tts-chat/config"
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+)
+
+// 启动本地模拟开放平台，并让应用管理器连接到它
+// 用法: go run ./bili/fakeserver/fakeserver_example -addr 127.0.0.1:9090 -script script.json
+func main() {
+	addr := flag.String("addr", "127.0.0.1:9090", "模拟服务监听地址")
+	scriptPath := flag.String("script", "", "事件脚本文件（ScriptedEvent 数组）")
+	compression := flag.Int("compression", int(bili.VER_ZLIB), "推送消息的协议版本: 0 不压缩, 2 zlib, 3 brotli")
+	withApp := flag.Bool("app", true, "是否在同一进程内启动应用管理器")
+	flag.Parse()
+
+	server := fakeserver.New(fakeserver.Options{
+		AccessKey:   config.GetBiliAccessKey(),
+		SecretKey:   config.GetBiliSecretKey(),
+		AppID:       int64(config.GetBiliAppID()),
+		Compression: int16(*compression),
+	})
+	if err := server.Start(*addr); err != nil {
+		logger.Error("启动模拟服务失败", "error", err)
+		return
+	}
+	defer server.Close()
+	config.SetOpenPlatformHttpHost(server.URL())
+
+	if !*withApp {
+		logger.Info(fmt.Sprintf("在 .env 中设置 bili_open_platform_host=%s 以连接模拟服务", server.URL()))
+	} else {
+		appManager := bili.NewAppManager()
+		if err := appManager.Start(); err != nil {
+			logger.Error("启动应用失败", "error", err)
+			return
+		}
+		defer appManager.Stop()
+	}
+
+	if *scriptPath == "" {
+		go pushDemoEvents(server)
+	} else {
+		script, err := fakeserver.LoadScript(*scriptPath)
+		if err != nil {
+			logger.Error("加载脚本失败", "error", err)
+			return
+		}
+		go server.RunScript(context.Background(), script)
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+	<-c
+}
+
+// pushDemoEvents 没有脚本时推送一组示例事件
+func pushDemoEvents(server *fakeserver.Server) {
+	time.Sleep(3 * time.Second)
+	now := time.Now().Unix()
+	server.Push(
+		fakeserver.Event{Cmd: "LIVE_OPEN_PLATFORM_DM", Data: map[string]any{
+			"open_id": "demo-user-1", "uname": "测试观众", "msg": "主播好", "msg_id": "demo-dm-1", "timestamp": now,
+		}},
+		fakeserver.Event{Cmd: "LIVE_OPEN_PLATFORM_SEND_GIFT", Data: map[string]any{
+			"open_id": "demo-user-2", "uname": "测试老板", "gift_id": 1, "gift_name": "小心心", "gift_num": 1,
+			"price": 0, "msg_id": "demo-gift-1", "timestamp": now,
+		}},
+	)
+}
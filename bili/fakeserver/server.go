@@ -0,0 +1,352 @@
+// Package fakeserver 本地模拟的B站直播开放平台，用于离线开发和联调
+// 提供 /v2/app/start、/v2/app/heartbeat、/v2/app/end 三个HTTP接口（校验签名），
+// 以及一个使用开放平台二进制协议的长连服务，可以推送脚本化的直播事件
+package fakeserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/bili"
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+)
+
+// requestExpireWindow 请求时间戳允许的误差
+const requestExpireWindow = 10 * time.Minute
+
+// Options 模拟服务配置
+type Options struct {
+	AccessKey  string // 校验签名使用的Access Key
+	SecretKey  string // 校验签名使用的Access Key Secret
+	AppID      int64  // 允许的应用id，为0时不校验
+	RoomIDCode string // 允许的身份码，为空时接受任意身份码
+	RoomID     int64  // 模拟的直播间id，多个身份码时依次递增
+
+	// ExtraWssLinks 追加在真实长连地址之前的地址，可填入不可用的地址来模拟节点故障
+	ExtraWssLinks []string
+	// Compression 推送消息使用的协议版本，bili.VER_NORMAL / bili.VER_ZLIB / bili.VER_BROTLI
+	Compression int16
+}
+
+// game 一个场次
+type game struct {
+	id       string
+	code     string
+	authBody string
+	lastBeat time.Time
+}
+
+// Server 模拟的开放平台服务
+type Server struct {
+	opts Options
+
+	mu      sync.Mutex
+	games   map[string]*game    // game_id -> 场次
+	nonces  map[string]struct{} // 已使用的签名随机数
+	conns   map[*conn]struct{}  // 已鉴权的长连
+	roomIDs map[string]int64    // 身份码 -> 直播间id
+
+	listener   net.Listener
+	httpServer *http.Server
+}
+
+// New 创建模拟服务
+func New(opts Options) *Server {
+	if opts.RoomID == 0 {
+		opts.RoomID = 10000
+	}
+	return &Server{
+		opts:    opts,
+		games:   make(map[string]*game),
+		nonces:  make(map[string]struct{}),
+		conns:   make(map[*conn]struct{}),
+		roomIDs: make(map[string]int64),
+	}
+}
+
+// Start 在指定地址启动服务，addr 为空时使用随机端口
+func (s *Server) Start(addr string) error {
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("监听地址失败: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(bili.PathAppStart, s.handleStart)
+	mux.HandleFunc(bili.PathAppHeartbeat, s.handleHeartbeat)
+	mux.HandleFunc(bili.PathAppEnd, s.handleEnd)
+	mux.HandleFunc("/sub", s.handleWebsocket)
+
+	s.listener = listener
+	s.httpServer = &http.Server{Handler: mux}
+	go func() {
+		if err := s.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger.Error(fmt.Sprintf("[FakeServer] 服务异常退出: %v", err))
+		}
+	}()
+
+	logger.Info(fmt.Sprintf("[FakeServer] 模拟开放平台已启动: %s", s.URL()))
+	return nil
+}
+
+// Close 关闭服务和所有长连
+func (s *Server) Close() error {
+	s.DropConnections()
+	if s.httpServer == nil {
+		return nil
+	}
+	return s.httpServer.Close()
+}
+
+// URL 返回HTTP接口地址，可传给 config.SetOpenPlatformHttpHost
+func (s *Server) URL() string {
+	if s.listener == nil {
+		return ""
+	}
+	return "http://" + s.listener.Addr().String()
+}
+
+// WssLink 返回长连地址
+func (s *Server) WssLink() string {
+	if s.listener == nil {
+		return ""
+	}
+	return "ws://" + s.listener.Addr().String() + "/sub"
+}
+
+// PushToGame 向指定场次的长连推送事件，用于模拟多个直播间
+func (s *Server) PushToGame(gameID string, events ...Event) error {
+	return s.pushToGame(gameID, events...)
+}
+
+// GameIDByCode 返回身份码对应的存活场次id
+func (s *Server) GameIDByCode(code string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, g := range s.games {
+		if g.code == code {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// GameIDs 返回当前存活的场次id
+func (s *Server) GameIDs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]string, 0, len(s.games))
+	for id := range s.games {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// ExpireGame 让场次失效并推送消息推送结束通知，模拟平台侧结束场次
+func (s *Server) ExpireGame(gameID string) error {
+	s.mu.Lock()
+	_, ok := s.games[gameID]
+	delete(s.games, gameID)
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("场次不存在: %s", gameID)
+	}
+
+	return s.pushToGame(gameID, Event{
+		Cmd: "LIVE_OPEN_PLATFORM_INTERACTION_END",
+		Data: map[string]any{
+			"game_id":   gameID,
+			"timestamp": time.Now().Unix(),
+		},
+	})
+}
+
+// handleStart 处理 /v2/app/start
+func (s *Server) handleStart(w http.ResponseWriter, r *http.Request) {
+	body, ok := s.verifyRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req bili.StartAppRequest
+	if err := json.Unmarshal(body, &req); err != nil || req.Code == "" {
+		writeResp(w, bili.CodeParamInvalid, "参数错误", nil)
+		return
+	}
+	if s.opts.AppID != 0 && req.AppId != s.opts.AppID {
+		writeResp(w, bili.CodeAppInvalid, "应用无效", nil)
+		return
+	}
+	if s.opts.RoomIDCode != "" && req.Code != s.opts.RoomIDCode {
+		writeResp(w, bili.CodeRoomCodeInvalid, "身份码错误", nil)
+		return
+	}
+
+	s.mu.Lock()
+	for _, g := range s.games {
+		if g.code == req.Code {
+			s.mu.Unlock()
+			writeResp(w, bili.CodeGameRepeated, "房间重复游戏", nil)
+			return
+		}
+	}
+	roomID, ok := s.roomIDs[req.Code]
+	if !ok {
+		roomID = s.opts.RoomID + int64(len(s.roomIDs))
+		s.roomIDs[req.Code] = roomID
+	}
+	g := &game{id: randomID(), code: req.Code, lastBeat: time.Now()}
+	authBody, _ := json.Marshal(map[string]any{"game_id": g.id, "room_id": roomID})
+	g.authBody = string(authBody)
+	s.games[g.id] = g
+	s.mu.Unlock()
+
+	logger.Info(fmt.Sprintf("[FakeServer] 场次已开启: %s", g.id))
+
+	links := append(append([]string{}, s.opts.ExtraWssLinks...), s.WssLink())
+	writeResp(w, bili.CodeOK, "ok", bili.StartAppRespData{
+		GameInfo: bili.GameInfo{GameId: g.id},
+		WebsocketInfo: bili.WebSocketInfo{
+			AuthBody: g.authBody,
+			WssLink:  links,
+		},
+		AnchorInfo: bili.AnchorInfo{
+			RoomId: roomID,
+			Uname:  "模拟主播",
+			OpenId: "fake-anchor-open-id",
+		},
+	})
+}
+
+// handleHeartbeat 处理 /v2/app/heartbeat
+func (s *Server) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	body, ok := s.verifyRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req bili.AppHeartbeatReq
+	if err := json.Unmarshal(body, &req); err != nil || req.GameId == "" {
+		writeResp(w, bili.CodeParamInvalid, "参数错误", nil)
+		return
+	}
+
+	s.mu.Lock()
+	g, exists := s.games[req.GameId]
+	if exists {
+		g.lastBeat = time.Now()
+	}
+	s.mu.Unlock()
+
+	if !exists {
+		writeResp(w, bili.CodeGameExpired, "心跳过期或GameId错误", nil)
+		return
+	}
+	writeResp(w, bili.CodeOK, "ok", struct{}{})
+}
+
+// handleEnd 处理 /v2/app/end
+func (s *Server) handleEnd(w http.ResponseWriter, r *http.Request) {
+	body, ok := s.verifyRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req bili.EndAppRequest
+	if err := json.Unmarshal(body, &req); err != nil || req.GameId == "" {
+		writeResp(w, bili.CodeParamInvalid, "参数错误", nil)
+		return
+	}
+
+	s.mu.Lock()
+	_, exists := s.games[req.GameId]
+	delete(s.games, req.GameId)
+	s.mu.Unlock()
+
+	if !exists {
+		writeResp(w, bili.CodeGameExpired, "场次不存在", nil)
+		return
+	}
+	s.closeGameConns(req.GameId)
+	logger.Info(fmt.Sprintf("[FakeServer] 场次已关闭: %s", req.GameId))
+	writeResp(w, bili.CodeOK, "ok", struct{}{})
+}
+
+// verifyRequest 校验请求方法、内容MD5、时间戳、随机数和签名，失败时直接写入错误响应
+func (s *Server) verifyRequest(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	if r.Method != http.MethodPost {
+		writeResp(w, bili.CodeParamInvalid, "无效Method", nil)
+		return nil, false
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeResp(w, bili.CodeParamInvalid, "读取请求失败", nil)
+		return nil, false
+	}
+
+	header := &bili.CommonHeader{
+		Timestamp:        r.Header.Get(bili.BiliTimestampHeader),
+		SignatureMethod:  r.Header.Get(bili.BiliSignatureMethodHeader),
+		SignatureVersion: r.Header.Get(bili.BiliSignVersionHeader),
+		Nonce:            r.Header.Get(bili.BiliSignatureNonceHeader),
+		AccessKeyId:      r.Header.Get(bili.BiliAccessKeyIdHeader),
+		ContentMD5:       r.Header.Get(bili.BiliContentMD5Header),
+	}
+
+	if header.AccessKeyId != s.opts.AccessKey || header.ContentMD5 != bili.Md5(string(body)) ||
+		r.Header.Get(bili.AuthorizationHeader) != bili.CreateSignature(header, s.opts.SecretKey) {
+		writeResp(w, bili.CodeSignInvalid, "签名异常", nil)
+		return nil, false
+	}
+
+	ts, err := strconv.ParseInt(header.Timestamp, 10, 64)
+	if err != nil || time.Since(time.Unix(ts, 0)).Abs() > requestExpireWindow {
+		writeResp(w, bili.CodeRequestExpired, "请求过期", nil)
+		return nil, false
+	}
+
+	s.mu.Lock()
+	_, used := s.nonces[header.Nonce]
+	s.nonces[header.Nonce] = struct{}{}
+	s.mu.Unlock()
+	if used {
+		writeResp(w, bili.CodeRequestRepeated, "重复请求", nil)
+		return nil, false
+	}
+
+	return body, true
+}
+
+// writeResp 写入开放平台格式的响应
+func writeResp(w http.ResponseWriter, code int64, message string, data any) {
+	resp := bili.BaseResp{
+		Code:      code,
+		Message:   message,
+		RequestId: randomID(),
+	}
+	if data != nil {
+		resp.Data, _ = json.Marshal(data)
+	}
+	w.Header().Set(bili.ContentTypeHeader, bili.JsonType)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// randomID 生成随机id
+func randomID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,239 @@
+package fakeserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/bili"
+	"github.com/CoffeeSwt/bilibili-tts-chat/handler"
+	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
+)
+
+const (
+	testAccessKey = "test-access-key"
+	testSecretKey = "test-secret-key"
+	testCode      = "TESTCODE"
+	testCmd       = "FAKESERVER_TEST_EVENT"
+)
+
+// discardSink 丢弃所有音频
+type discardSink struct{}
+
+func (discardSink) Play(ctx context.Context, audioData []byte) error { return nil }
+
+// startServer 启动一个随机端口的模拟服务
+func startServer(t *testing.T) *Server {
+	t.Helper()
+	s := New(Options{AccessKey: testAccessKey, SecretKey: testSecretKey, RoomIDCode: testCode})
+	if err := s.Start(""); err != nil {
+		t.Fatalf("启动模拟服务失败: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// newClient 创建连接到模拟服务的接口客户端
+func newClient(s *Server, secretKey string) *bili.Client {
+	return bili.NewClient(bili.ClientOptions{
+		BaseURL:    s.URL(),
+		AccessKey:  testAccessKey,
+		SecretKey:  secretKey,
+		MaxRetries: -1,
+	})
+}
+
+// waitFor 轮询直到条件成立或超时
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待超时: %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// lastBeat 返回场次最近一次心跳的时间
+func (s *Server) lastBeat(gameID string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if g, ok := s.games[gameID]; ok {
+		return g.lastBeat
+	}
+	return time.Time{}
+}
+
+func TestAppManagerLifecycle(t *testing.T) {
+	s := startServer(t)
+
+	am := bili.NewAppManagerWithOptions(bili.AppOptions{
+		Name:              "test",
+		RoomIDCode:        testCode,
+		Tasks:             task_manager.NewTaskManager(task_manager.Options{Name: "test", Sink: discardSink{}}),
+		Client:            newClient(s, testSecretKey),
+		HeartbeatInterval: 50 * time.Millisecond,
+	})
+
+	received := make(chan string, 16)
+	am.Handler().Registry().Register(testCmd, func(msg *handler.Message) error {
+		received <- msg.MsgID
+		return nil
+	})
+
+	// 开启场次
+	if err := am.Start(); err != nil {
+		t.Fatalf("启动应用失败: %v", err)
+	}
+	gameID, ok := s.GameIDByCode(testCode)
+	if !ok {
+		t.Fatal("模拟服务中没有开启的场次")
+	}
+
+	// 应用心跳
+	started := s.lastBeat(gameID)
+	waitFor(t, "应用心跳", func() bool { return s.lastBeat(gameID).After(started) })
+
+	// 推送事件，长连鉴权完成前推送会失败
+	push := func(gameID, msgID string) {
+		waitFor(t, "推送事件到场次 "+gameID, func() bool {
+			return s.PushToGame(gameID, Event{Cmd: testCmd, Data: map[string]any{"msg_id": msgID}}) == nil
+		})
+		select {
+		case got := <-received:
+			if got != msgID {
+				t.Fatalf("收到消息 %q, want %q", got, msgID)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("没有收到推送的消息 %s", msgID)
+		}
+	}
+	push(gameID, "msg-1")
+
+	// 平台结束场次后重新开启
+	if err := s.ExpireGame(gameID); err != nil {
+		t.Fatalf("ExpireGame: %v", err)
+	}
+	var newGameID string
+	waitFor(t, "重新开启场次", func() bool {
+		id, ok := s.GameIDByCode(testCode)
+		newGameID = id
+		return ok && id != gameID
+	})
+	push(newGameID, "msg-2")
+
+	// 关闭场次
+	if err := am.Stop(); err != nil {
+		t.Fatalf("停止应用失败: %v", err)
+	}
+	if ids := s.GameIDs(); len(ids) != 0 {
+		t.Errorf("停止后仍有存活的场次: %v", ids)
+	}
+}
+
+// TestAppManagerRepeatedRestart 场次反复过期时，新的长连替换旧的长连，旧长连的读取可能仍在断开连接
+// 需要用 go test -race 运行
+func TestAppManagerRepeatedRestart(t *testing.T) {
+	s := startServer(t)
+
+	am := bili.NewAppManagerWithOptions(bili.AppOptions{
+		Name:              "test",
+		RoomIDCode:        testCode,
+		Tasks:             task_manager.NewTaskManager(task_manager.Options{Name: "test", Sink: discardSink{}}),
+		Client:            newClient(s, testSecretKey),
+		HeartbeatInterval: 50 * time.Millisecond,
+	})
+	if err := am.Start(); err != nil {
+		t.Fatalf("启动应用失败: %v", err)
+	}
+	t.Cleanup(func() { am.Stop() })
+
+	gameID, _ := s.GameIDByCode(testCode)
+	for i := 0; i < 3; i++ {
+		// 长连鉴权完成后才能推送结束消息
+		id := gameID
+		waitFor(t, "长连鉴权 "+id, func() bool {
+			return s.PushToGame(id, Event{Cmd: testCmd, Data: map[string]any{"msg_id": id}}) == nil
+		})
+		if err := s.ExpireGame(id); err != nil {
+			t.Fatalf("ExpireGame: %v", err)
+		}
+		old := gameID
+		waitFor(t, "重新开启场次", func() bool {
+			id, ok := s.GameIDByCode(testCode)
+			gameID = id
+			return ok && id != old
+		})
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	s := startServer(t)
+	ctx := context.Background()
+
+	t.Run("签名错误", func(t *testing.T) {
+		_, err := newClient(s, "wrong-secret").StartApp(ctx, testCode, 0)
+		if !errors.Is(err, bili.ErrSignInvalid) {
+			t.Fatalf("err = %v, want %v", err, bili.ErrSignInvalid)
+		}
+	})
+
+	t.Run("重复的随机数", func(t *testing.T) {
+		body := []byte(`{"game_id":"unknown"}`)
+		header := &bili.CommonHeader{
+			ContentType:       bili.JsonType,
+			ContentAcceptType: bili.JsonType,
+			Timestamp:         strconv.FormatInt(time.Now().Unix(), 10),
+			SignatureMethod:   bili.HmacSha256,
+			SignatureVersion:  bili.BiliVersion,
+			Nonce:             "fixed-nonce",
+			AccessKeyId:       testAccessKey,
+			ContentMD5:        bili.Md5(string(body)),
+		}
+		header.Authorization = bili.CreateSignature(header, testSecretKey)
+
+		send := func() int64 {
+			req, _ := http.NewRequest(http.MethodPost, s.URL()+bili.PathAppHeartbeat, bytes.NewReader(body))
+			for k, v := range header.ToMap() {
+				req.Header.Set(k, v)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("请求失败: %v", err)
+			}
+			defer resp.Body.Close()
+			var base bili.BaseResp
+			if err := json.NewDecoder(resp.Body).Decode(&base); err != nil {
+				t.Fatalf("解析响应失败: %v", err)
+			}
+			return base.Code
+		}
+
+		if code := send(); code != bili.CodeGameExpired {
+			t.Fatalf("首次请求 code = %d, want %d", code, bili.CodeGameExpired)
+		}
+		if code := send(); code != bili.CodeRequestRepeated {
+			t.Fatalf("重复请求 code = %d, want %d", code, bili.CodeRequestRepeated)
+		}
+	})
+
+	t.Run("场次不存在", func(t *testing.T) {
+		err := newClient(s, testSecretKey).AppHeartbeat(ctx, "unknown")
+		if !errors.Is(err, bili.ErrGameExpired) {
+			t.Fatalf("err = %v, want %v", err, bili.ErrGameExpired)
+		}
+	})
+
+	t.Run("身份码错误", func(t *testing.T) {
+		_, err := newClient(s, testSecretKey).StartApp(ctx, "OTHER", 0)
+		if !errors.Is(err, bili.ErrRoomCodeInvalid) {
+			t.Fatalf("err = %v, want %v", err, bili.ErrRoomCodeInvalid)
+		}
+	})
+}
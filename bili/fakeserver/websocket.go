@@ -0,0 +1,229 @@
+package fakeserver
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/bili"
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gorilla/websocket"
+)
+
+// Event 推送给客户端的直播事件
+type Event struct {
+	Cmd  string `json:"cmd"`  // 消息类型，如 LIVE_OPEN_PLATFORM_DM
+	Data any    `json:"data"` // 消息数据
+}
+
+// ScriptedEvent 脚本中的一条事件
+type ScriptedEvent struct {
+	DelayMs int64           `json:"delay_ms"` // 距上一条事件的等待时间（毫秒）
+	Cmd     string          `json:"cmd"`      // 消息类型
+	Data    json.RawMessage `json:"data"`     // 消息数据
+}
+
+// conn 一个已建立的长连
+type conn struct {
+	ws      *websocket.Conn
+	writeMu sync.Mutex
+	gameID  string
+	seq     int32
+}
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// handleWebsocket 处理长连，支持 OP_AUTH 和 OP_HEARTBEAT
+func (s *Server) handleWebsocket(w http.ResponseWriter, r *http.Request) {
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[FakeServer] 长连升级失败: %v", err))
+		return
+	}
+	c := &conn{ws: ws}
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		ws.Close()
+	}()
+
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		for _, p := range bili.UnpackFrame(data) {
+			switch p.Operation {
+			case bili.OP_AUTH:
+				if !s.auth(c, p.Body) {
+					c.write(bili.OP_AUTH_REPLY, bili.VER_NORMAL, []byte(`{"code":-101}`))
+					return
+				}
+				c.write(bili.OP_AUTH_REPLY, bili.VER_NORMAL, []byte(`{"code":0}`))
+			case bili.OP_HEARTBEAT:
+				popularity := make([]byte, 4)
+				binary.BigEndian.PutUint32(popularity, 1)
+				c.write(bili.OP_HEARTBEAT_REPLY, bili.VER_HEARTBEAT, popularity)
+			}
+		}
+	}
+}
+
+// auth 校验鉴权包中的auth_body是否属于存活的场次
+func (s *Server) auth(c *conn, authBody []byte) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, g := range s.games {
+		if g.authBody == string(authBody) {
+			c.gameID = id
+			s.conns[c] = struct{}{}
+			logger.Info(fmt.Sprintf("[FakeServer] 长连鉴权成功，场次: %s", id))
+			return true
+		}
+	}
+	logger.Warn("[FakeServer] 长连鉴权失败，auth_body无效")
+	return false
+}
+
+// write 发送一个包
+func (c *conn) write(op int32, ver int16, body []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.seq++
+	p := &bili.Proto{Operation: op, Version: ver, SequenceId: c.seq, Body: body}
+	c.ws.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	return c.ws.WriteMessage(websocket.BinaryMessage, p.Pack())
+}
+
+// Push 向所有已鉴权的长连推送事件
+// 多个事件会放在同一帧中：压缩模式下作为嵌套包，否则首尾相连
+func (s *Server) Push(events ...Event) error {
+	return s.pushToGame("", events...)
+}
+
+// pushToGame 向指定场次的长连推送事件，gameID 为空时推送给所有长连
+func (s *Server) pushToGame(gameID string, events ...Event) error {
+	frame, ver, err := s.encodeEvents(events)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	targets := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		if gameID == "" || c.gameID == gameID {
+			targets = append(targets, c)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(targets) == 0 {
+		return fmt.Errorf("没有可推送的长连")
+	}
+	for _, c := range targets {
+		if ver == bili.VER_NORMAL {
+			c.writeMu.Lock()
+			c.ws.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			err = c.ws.WriteMessage(websocket.BinaryMessage, frame)
+			c.writeMu.Unlock()
+		} else {
+			err = c.write(bili.OP_SEND_SMS_REPLY, ver, frame)
+		}
+		if err != nil {
+			logger.Error(fmt.Sprintf("[FakeServer] 推送事件失败: %v", err))
+		}
+	}
+	return nil
+}
+
+// encodeEvents 将事件编码为首尾相连的包，压缩模式下返回压缩后的消息体
+func (s *Server) encodeEvents(events []Event) ([]byte, int16, error) {
+	var packets bytes.Buffer
+	for _, e := range events {
+		body, err := json.Marshal(e)
+		if err != nil {
+			return nil, 0, fmt.Errorf("序列化事件失败: %w", err)
+		}
+		p := &bili.Proto{Operation: bili.OP_SEND_SMS_REPLY, Version: bili.VER_NORMAL, Body: body}
+		packets.Write(p.Pack())
+	}
+
+	var compressed bytes.Buffer
+	switch s.opts.Compression {
+	case bili.VER_ZLIB:
+		zw := zlib.NewWriter(&compressed)
+		zw.Write(packets.Bytes())
+		zw.Close()
+	case bili.VER_BROTLI:
+		bw := brotli.NewWriter(&compressed)
+		bw.Write(packets.Bytes())
+		bw.Close()
+	default:
+		return packets.Bytes(), bili.VER_NORMAL, nil
+	}
+	return compressed.Bytes(), s.opts.Compression, nil
+}
+
+// DropConnections 断开所有长连，模拟网络故障
+func (s *Server) DropConnections() {
+	s.closeGameConns("")
+}
+
+// closeGameConns 断开指定场次的长连，gameID 为空时断开全部
+func (s *Server) closeGameConns(gameID string) {
+	s.mu.Lock()
+	var targets []*conn
+	for c := range s.conns {
+		if gameID == "" || c.gameID == gameID {
+			targets = append(targets, c)
+			delete(s.conns, c)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, c := range targets {
+		c.ws.Close()
+	}
+}
+
+// LoadScript 从JSON文件加载事件脚本，文件内容为 ScriptedEvent 数组
+func LoadScript(path string) ([]ScriptedEvent, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取脚本失败: %w", err)
+	}
+	var script []ScriptedEvent
+	if err := json.Unmarshal(content, &script); err != nil {
+		return nil, fmt.Errorf("解析脚本失败: %w", err)
+	}
+	return script, nil
+}
+
+// RunScript 按脚本中的间隔依次推送事件，直到脚本结束或上下文取消
+func (s *Server) RunScript(ctx context.Context, script []ScriptedEvent) error {
+	for _, e := range script {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(e.DelayMs) * time.Millisecond):
+		}
+		if err := s.Push(Event{Cmd: e.Cmd, Data: e.Data}); err != nil {
+			logger.Warn(fmt.Sprintf("[FakeServer] 脚本事件 %s 推送失败: %v", e.Cmd, err))
+		}
+	}
+	return nil
+}
@@ -2,9 +2,10 @@ package bili
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"os"
 	"os/signal"
 	"sync"
@@ -12,20 +13,52 @@ import (
 	"time"
 
 	"github.com/CoffeeSwt/bilibili-tts-chat/config"
+	"github.com/CoffeeSwt/bilibili-tts-chat/eventsource"
+	"github.com/CoffeeSwt/bilibili-tts-chat/handler"
 	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/recorder"
 	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
+	"github.com/CoffeeSwt/bilibili-tts-chat/user"
 )
 
 // AppManager 应用管理器，封装所有B站相关的逻辑
 type AppManager struct {
-	gameID        string
-	appID         int64
-	heartbeatStop chan struct{}
-	ctx           context.Context
-	cancel        context.CancelFunc
-	wg            sync.WaitGroup
-	isRunning     bool
-	mu            sync.RWMutex
+	gameID            string
+	appID             int64
+	heartbeatStop     chan struct{}
+	heartbeatInterval time.Duration
+	ctx               context.Context
+	cancel            context.CancelFunc
+	wg                sync.WaitGroup
+	isRunning         bool
+	mu                sync.RWMutex
+
+	// 场次监控
+	sessionMu         sync.RWMutex     // 保护gameID和ws，场次重建时不持有mu，避免与Stop互相等待
+	restartCh         chan string      // 需要重建场次的原因
+	heartbeatFailures int              // 应用心跳连续失败次数
+	ws                *WebsocketClient // 当前场次的长连
+
+	// 直播间
+	name       string                    // 直播间名称，用于日志和录制文件名
+	roomIDCode string                    // 直播间身份码，为空时使用 user.json 中的配置
+	tasks      *task_manager.TaskManager // 直播间的任务管理器
+	handler    *handler.MessageHandler   // 直播间的消息处理器
+	recording  *recorder.Session         // 录制会话
+	client     *Client                   // 开放平台接口客户端
+	stream     *eventsource.Stream       // 合并所有事件来源的事件流
+	manual     *eventsource.Manual       // 手动弹幕
+}
+
+// AppOptions 应用管理器配置，多直播间时每个直播间创建一个应用管理器
+type AppOptions struct {
+	Name       string                    // 直播间名称
+	RoomIDCode string                    // 直播间身份码，为空时使用 user.json 中的配置
+	Tasks      *task_manager.TaskManager // 任务管理器，为空时使用全局任务管理器
+	Client     *Client                   // 开放平台接口客户端，为空时使用默认配置创建
+	Sources    []eventsource.Source      // 长连之外的事件来源，如本地Webhook、文件跟踪
+
+	HeartbeatInterval time.Duration // 应用心跳间隔，为0时使用20秒
 }
 
 // StartAppRequest 启动应用请求
@@ -74,13 +107,105 @@ type AppHeartbeatReq struct {
 
 // NewAppManager 创建新的应用管理器
 func NewAppManager() *AppManager {
+	return NewAppManagerWithOptions(AppOptions{
+		Sources: eventsource.DefaultOptions().Sources(),
+	})
+}
+
+// NewAppManagerWithOptions 使用指定的直播间配置创建应用管理器
+func NewAppManagerWithOptions(opts AppOptions) *AppManager {
+	if opts.Tasks == nil {
+		opts.Tasks = task_manager.GetInstance()
+	}
+	if opts.Client == nil {
+		opts.Client = NewClient(ClientOptions{})
+	}
+	if opts.HeartbeatInterval <= 0 {
+		opts.HeartbeatInterval = 20 * time.Second
+	}
 	ctx, cancel := context.WithCancel(context.Background())
+	messageHandler := handler.NewMessageHandler(opts.Tasks)
+	stream := eventsource.NewStream(messageHandler.HandleMessage)
+	manual := eventsource.NewManual()
+	stream.Add(manual)
+	for _, src := range opts.Sources {
+		stream.Add(src)
+	}
 	return &AppManager{
-		appID:         int64(config.GetBiliAppID()),
-		heartbeatStop: make(chan struct{}),
-		ctx:           ctx,
-		cancel:        cancel,
+		appID:             int64(config.GetBiliAppID()),
+		heartbeatStop:     make(chan struct{}),
+		heartbeatInterval: opts.HeartbeatInterval,
+		ctx:               ctx,
+		cancel:            cancel,
+		restartCh:         make(chan string, 1),
+		name:              opts.Name,
+		roomIDCode:        opts.RoomIDCode,
+		tasks:             opts.Tasks,
+		handler:           messageHandler,
+		client:            opts.Client,
+		stream:            stream,
+		manual:            manual,
+	}
+}
+
+// Name 返回直播间名称
+func (am *AppManager) Name() string {
+	return am.name
+}
+
+// Tasks 返回直播间的任务管理器
+func (am *AppManager) Tasks() *task_manager.TaskManager {
+	return am.tasks
+}
+
+// Handler 返回直播间的消息处理器，可以通过 Registry 注册新的消息类型或中间件
+func (am *AppManager) Handler() *handler.MessageHandler {
+	return am.handler
+}
+
+// GetStats 获取直播间统计信息，包括任务管理器和消息去重统计
+func (am *AppManager) GetStats() map[string]interface{} {
+	stats := am.tasks.GetStats()
+	for k, v := range am.handler.GetStats() {
+		stats[k] = v
+	}
+	return stats
+}
+
+// AddSource 添加事件来源，应用运行中时立即启动
+func (am *AppManager) AddSource(src eventsource.Source) {
+	am.stream.Add(src)
+}
+
+// SendDanmaku 注入一条手动弹幕，与真实弹幕走相同的语音和LLM流程，uname 为空时使用默认昵称
+func (am *AppManager) SendDanmaku(uname, msg string) error {
+	if !am.IsRunning() {
+		return fmt.Errorf("应用未运行")
+	}
+	return am.manual.Danmaku(uname, msg)
+}
+
+// IsRunning 是否正在运行
+func (am *AppManager) IsRunning() bool {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	return am.isRunning
+}
+
+// logPrefix 多直播间时在日志前加上直播间名称
+func (am *AppManager) logPrefix() string {
+	if am.name == "" {
+		return ""
+	}
+	return fmt.Sprintf("[%s] ", am.name)
+}
+
+// getRoomIDCode 获取直播间身份码
+func (am *AppManager) getRoomIDCode() string {
+	if am.roomIDCode != "" {
+		return am.roomIDCode
 	}
+	return config.GetRoomIDCode()
 }
 
 // Start 启动应用
@@ -92,7 +217,7 @@ func (am *AppManager) Start() error {
 		return fmt.Errorf("应用已经在运行中")
 	}
 
-	logger.Info("正在启动应用管理器...")
+	logger.Info(am.logPrefix() + "正在启动应用管理器...")
 
 	// 启动B站应用
 	startAppResp, err := am.startApp()
@@ -103,16 +228,33 @@ func (am *AppManager) Start() error {
 	// 启动心跳
 	am.startHeartbeat()
 
+	// 开始录制直播事件
+	if config.GetRecordEvents() {
+		session, err := recorder.StartSession(am.name)
+		if err != nil {
+			logger.Error(fmt.Sprintf("开始录制直播事件失败: %v", err))
+		}
+		am.recording = session
+		am.handler.SetRecorder(session)
+	}
+
+	// 启动事件流，长连收到的消息和其他事件来源的消息按到达顺序处理
+	am.stream.Start(am.ctx)
+
 	// 启动WebSocket连接
 	if err := am.startWebSocket(startAppResp); err != nil {
+		am.abortStart()
 		return fmt.Errorf("启动WebSocket连接失败: %w", err)
 	}
 
 	// 启动事件驱动任务处理器
 	am.startEventDrivenTaskProcessor()
 
+	// 启动场次监控
+	am.startSupervisor()
+
 	am.isRunning = true
-	logger.Info("应用管理器启动成功")
+	logger.Info(am.logPrefix() + "应用管理器启动成功")
 	return nil
 }
 
@@ -125,7 +267,7 @@ func (am *AppManager) Stop() error {
 		return nil
 	}
 
-	logger.Info("正在停止应用管理器...")
+	logger.Info(am.logPrefix() + "正在停止应用管理器...")
 
 	// 停止心跳
 	close(am.heartbeatStop)
@@ -135,27 +277,66 @@ func (am *AppManager) Stop() error {
 
 	// 等待所有goroutine结束
 	am.wg.Wait()
+	am.stream.Wait()
 
 	// 关闭B站应用
-	if am.gameID != "" {
+	if am.getGameID() != "" {
 		if err := am.endApp(); err != nil {
 			log.Printf("关闭B站应用失败: %v", err)
 		}
 	}
 
 	// 显式停止WebSocket连接
-	if err := StopWebsocket(); err != nil {
-		logger.Error("停止WebSocket连接失败", err)
+	am.sessionMu.Lock()
+	ws := am.ws
+	am.ws = nil
+	am.sessionMu.Unlock()
+	if ws != nil {
+		if err := ws.Shutdown(); err != nil {
+			logger.Error("停止WebSocket连接失败", err)
+		}
 	}
 
+	// 结束录制
+	am.stopRecording()
+
+	// 停止消息处理器中等待合并的播报
+	am.handler.Close()
+
 	// 清理任务管理器状态
-	task_manager.ClearTasks()
+	am.tasks.ClearTasks()
+
+	// 保存等待延迟写入的送礼价值
+	if err := user.FlushGiftValues(); err != nil {
+		logger.Error(fmt.Sprintf("保存用户送礼价值失败: %v", err))
+	}
 
 	am.isRunning = false
-	logger.Info("应用管理器已停止")
+	logger.Info(am.logPrefix() + "应用管理器已停止")
 	return nil
 }
 
+// abortStart 启动失败时停止已启动的心跳和事件流，结束录制并关闭已开启的场次
+// 调用时需持有 mu，之后应用管理器不能再次启动
+func (am *AppManager) abortStart() {
+	close(am.heartbeatStop)
+	am.cancel()
+	am.wg.Wait()
+	am.stream.Wait()
+
+	if am.getGameID() != "" {
+		if err := am.endApp(); err != nil {
+			logger.Error(fmt.Sprintf("%s关闭B站应用失败: %v", am.logPrefix(), err))
+		}
+		am.sessionMu.Lock()
+		am.gameID = ""
+		am.sessionMu.Unlock()
+	}
+
+	am.stopRecording()
+	am.handler.Close()
+}
+
 // WaitForShutdown 等待关闭信号
 func (am *AppManager) WaitForShutdown() {
 	c := make(chan os.Signal, 1)
@@ -178,27 +359,23 @@ func (am *AppManager) WaitForShutdown() {
 // startApp 启动B站应用
 func (am *AppManager) startApp() (*StartAppRespData, error) {
 	logger.Info("正在启动B站应用...")
-	logger.Info(fmt.Sprintf("StartApp请求参数: Code=%s, AppId=%d", config.GetRoomIDCode(), am.appID))
-	resp, err := am.StartApp(config.GetRoomIDCode(), am.appID)
+	logger.Info(fmt.Sprintf("StartApp请求参数: Code=%s, AppId=%d", am.getRoomIDCode(), am.appID))
+	startAppRespData, err := am.StartApp(am.ctx, am.getRoomIDCode(), am.appID)
 	if err != nil {
+		if errors.Is(err, ErrRoomCodeInvalid) {
+			return nil, fmt.Errorf("身份码错误，请检查配置: %w", err)
+		}
+		if errors.Is(err, ErrGameRepeated) {
+			// 上一次开启的场次仍然存活（如响应丢失），拿不到它的场次id，只能等它心跳过期
+			return nil, fmt.Errorf("直播间已有进行中的场次，等待其心跳过期后重试: %w", err)
+		}
 		return nil, fmt.Errorf("StartApp API调用失败: %w", err)
 	}
 
-	if resp.Code != 0 {
-		return nil, fmt.Errorf("StartApp API返回错误: Code=%d, Message=%s", resp.Code, resp.Message)
-	}
-
-	if len(resp.Data) == 0 {
-		return nil, fmt.Errorf("StartApp API返回的Data为空")
-	}
-
-	startAppRespData := &StartAppRespData{}
-	err = json.Unmarshal(resp.Data, startAppRespData)
-	if err != nil {
-		return nil, fmt.Errorf("解析StartApp响应数据失败: %w", err)
-	}
-
+	am.sessionMu.Lock()
 	am.gameID = startAppRespData.GameInfo.GameId
+	am.sessionMu.Unlock()
+	am.handler.SetAnchor(startAppRespData.AnchorInfo.OpenId)
 	logger.Info("B站应用启动成功")
 	return startAppRespData, nil
 }
@@ -208,18 +385,15 @@ func (am *AppManager) startHeartbeat() {
 	am.wg.Add(1)
 	go func() {
 		defer am.wg.Done()
-		ticker := time.NewTicker(20 * time.Second)
+		ticker := time.NewTicker(am.heartbeatInterval)
 		defer ticker.Stop()
 
 		logger.Info("正在启动心跳服务...")
 		for {
 			select {
 			case <-ticker.C:
-				if am.gameID != "" {
-					_, err := am.AppHeart(am.gameID)
-					if err != nil {
-						logger.Error("心跳发送失败", err)
-					}
+				if gameID := am.getGameID(); gameID != "" {
+					am.recordHeartbeat(am.AppHeart(am.ctx, gameID))
 				}
 			case <-am.heartbeatStop:
 				logger.Info("心跳服务已停止")
@@ -239,11 +413,29 @@ func (am *AppManager) startWebSocket(startAppResp *StartAppRespData) error {
 	}
 
 	logger.Info("正在启动WebSocket连接...")
-	err := StartWebsocket(startAppResp.WebsocketInfo.WssLink[0], startAppResp.WebsocketInfo.AuthBody)
+	opts := DefaultWebsocketOptions()
+	opts.OnExhausted = func() {
+		am.requestRestart("WebSocket重连次数耗尽")
+	}
+	opts.MessageHandler = am.handler
+	opts.Stream = am.stream
+	ws, err := NewWebsocketClient(startAppResp.WebsocketInfo.WssLink, startAppResp.WebsocketInfo.AuthBody, opts)
 	if err != nil {
 		return fmt.Errorf("启动WebSocket失败: %w", err)
 	}
 
+	// 替换旧场次的长连
+	am.sessionMu.Lock()
+	old := am.ws
+	am.ws = ws
+	am.sessionMu.Unlock()
+	if old != nil {
+		logger.Info("发现旧的WebSocket连接，正在关闭...")
+		if err := old.Shutdown(); err != nil {
+			logger.Error("关闭旧WebSocket连接失败", err)
+		}
+	}
+
 	logger.Info("WebSocket连接启动成功")
 	return nil
 }
@@ -251,8 +443,10 @@ func (am *AppManager) startWebSocket(startAppResp *StartAppRespData) error {
 // endApp 关闭B站应用
 func (am *AppManager) endApp() error {
 	logger.Info("正在关闭B站应用...")
-	_, err := am.EndApp(am.gameID, am.appID)
-	if err != nil {
+	// 停止时上下文已取消，单独设置超时
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := am.EndApp(ctx, am.getGameID(), am.appID); err != nil {
 		return err
 	}
 	logger.Info("B站应用关闭成功")
@@ -260,35 +454,146 @@ func (am *AppManager) endApp() error {
 }
 
 // StartApp 启动app
-func (am *AppManager) StartApp(code string, appId int64) (resp BaseResp, err error) {
-	startAppReq := StartAppRequest{
-		Code:  code,
-		AppId: appId,
+func (am *AppManager) StartApp(ctx context.Context, code string, appId int64) (*StartAppRespData, error) {
+	return am.client.StartApp(ctx, code, appId)
+}
+
+// AppHeart app心跳
+func (am *AppManager) AppHeart(ctx context.Context, gameId string) error {
+	return am.client.AppHeartbeat(ctx, gameId)
+}
+
+// EndApp 关闭app
+func (am *AppManager) EndApp(ctx context.Context, gameId string, appId int64) error {
+	return am.client.EndApp(ctx, gameId, appId)
+}
+
+// stopRecording 结束录制会话
+func (am *AppManager) stopRecording() {
+	if am.recording == nil {
+		return
 	}
-	reqJson, err := json.Marshal(startAppReq)
-	if err != nil {
-		return resp, fmt.Errorf("序列化StartApp请求参数失败: %w", err)
+	am.handler.SetRecorder(nil)
+	if err := am.recording.Close(); err != nil {
+		logger.Error(fmt.Sprintf("结束录制失败: %v", err))
 	}
-	return ApiRequest(string(reqJson), "/v2/app/start")
+	am.recording = nil
 }
 
-// AppHeart app心跳
-func (am *AppManager) AppHeart(gameId string) (resp BaseResp, err error) {
-	appHeartbeatReq := AppHeartbeatReq{
-		GameId: gameId,
+// getGameID 获取当前场次id
+func (am *AppManager) getGameID() string {
+	am.sessionMu.RLock()
+	defer am.sessionMu.RUnlock()
+	return am.gameID
+}
+
+// recordHeartbeat 记录应用心跳结果，连续失败过多时重建场次
+func (am *AppManager) recordHeartbeat(err error) {
+	am.sessionMu.Lock()
+	if err == nil {
+		am.heartbeatFailures = 0
+		am.sessionMu.Unlock()
+		return
+	}
+	am.heartbeatFailures++
+	failures := am.heartbeatFailures
+	am.sessionMu.Unlock()
+
+	logger.Error(fmt.Sprintf("心跳发送失败 (连续 %d 次): %v", failures, err))
+	if errors.Is(err, ErrGameExpired) {
+		// 场次已失效，继续发送心跳没有意义
+		am.requestRestart("场次已失效")
+		return
+	}
+	if failures >= config.GetAppHeartbeatMaxFailures() {
+		am.requestRestart("应用心跳连续失败")
 	}
-	reqJson, _ := json.Marshal(appHeartbeatReq)
-	return ApiRequest(string(reqJson), "/v2/app/heartbeat")
 }
 
-// EndApp 关闭app
-func (am *AppManager) EndApp(gameId string, appId int64) (resp BaseResp, err error) {
-	endAppReq := EndAppRequest{
-		GameId: gameId,
-		AppId:  appId,
+// requestRestart 请求重建场次，已有待处理的请求时忽略
+func (am *AppManager) requestRestart(reason string) {
+	select {
+	case am.restartCh <- reason:
+		logger.Warn(fmt.Sprintf("请求重新建立场次: %s", reason))
+	default:
 	}
-	reqJson, _ := json.Marshal(endAppReq)
-	return ApiRequest(string(reqJson), "/v2/app/end")
+}
+
+// startSupervisor 启动场次监控，处理重连耗尽、心跳失败和消息推送结束
+func (am *AppManager) startSupervisor() {
+	am.handler.SetInteractionEndCallback(func(gameID string) {
+		if gameID == "" || gameID == am.getGameID() {
+			am.requestRestart("消息推送结束")
+		}
+	})
+
+	am.wg.Add(1)
+	go func() {
+		defer am.wg.Done()
+		for {
+			select {
+			case <-am.ctx.Done():
+				logger.Info("场次监控已停止")
+				return
+			case reason := <-am.restartCh:
+				am.restartSessionWithBackoff(reason)
+			}
+		}
+	}()
+}
+
+// restartSessionWithBackoff 按指数退避重建场次，直到成功或应用停止
+func (am *AppManager) restartSessionWithBackoff(reason string) {
+	baseDelay := config.GetWsReconnectBaseDelay()
+	maxDelay := config.GetWsReconnectMaxDelay()
+
+	for attempt := 0; ; attempt++ {
+		err := am.restartSession(reason)
+		if err == nil {
+			return
+		}
+
+		delay := min(time.Duration(float64(baseDelay)*math.Pow(2, float64(attempt))), maxDelay)
+		logger.Error(fmt.Sprintf("重新建立场次失败 (第 %d 次)，%v 后重试: %v", attempt+1, delay, err))
+		select {
+		case <-am.ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// restartSession 结束旧场次，开启新场次并替换WebSocket连接
+func (am *AppManager) restartSession(reason string) error {
+	logger.Info(fmt.Sprintf("%s正在重新建立场次，原因: %s", am.logPrefix(), reason))
+
+	if oldGameID := am.getGameID(); oldGameID != "" {
+		ctx, cancel := context.WithTimeout(am.ctx, 10*time.Second)
+		err := am.EndApp(ctx, oldGameID, am.appID)
+		cancel()
+		if err != nil && !errors.Is(err, ErrGameExpired) {
+			logger.Warn(fmt.Sprintf("关闭旧场次 %s 失败: %v", oldGameID, err))
+		}
+		am.sessionMu.Lock()
+		am.gameID = ""
+		am.sessionMu.Unlock()
+	}
+
+	startAppResp, err := am.startApp()
+	if err != nil {
+		return err
+	}
+
+	if err := am.startWebSocket(startAppResp); err != nil {
+		return err
+	}
+
+	am.sessionMu.Lock()
+	am.heartbeatFailures = 0
+	am.sessionMu.Unlock()
+
+	logger.Info(fmt.Sprintf("%s场次已重新建立: %s", am.logPrefix(), startAppResp.GameInfo.GameId))
+	return nil
 }
 
 // startEventDrivenTaskProcessor 启动事件驱动任务处理器
@@ -298,7 +603,7 @@ func (am *AppManager) startEventDrivenTaskProcessor() {
 		defer am.wg.Done()
 
 		logger.Info("事件驱动任务处理器已启动")
-		taskNotify := task_manager.GetTaskNotifyChannel()
+		taskNotify := am.tasks.NotifyChannel()
 
 		for {
 			select {
@@ -326,9 +631,9 @@ func (am *AppManager) processTaskLoop() {
 		}
 
 		// 检查是否有任务需要处理
-		if task_manager.IsTaskRunning() {
+		if am.tasks.IsTaskRunning() {
 			logger.Info("检测到有任务需要处理，开始执行...")
-			task_manager.PlayEventTasks(am.ctx)
+			am.tasks.PlayEventTasks(am.ctx)
 			// PlayEventTasks 完成后，立即检查是否还有新任务
 			logger.Info("任务执行完成，检查是否有新任务...")
 		} else {
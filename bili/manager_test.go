@@ -0,0 +1,53 @@
+package bili
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
+)
+
+// TestStartCleansUpOnWebsocketError 长连启动失败时应停止心跳并关闭已开启的场次
+func TestStartCleansUpOnWebsocketError(t *testing.T) {
+	var ended, beats atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := BaseResp{Code: CodeOK}
+		switch r.URL.Path {
+		case PathAppStart:
+			// 没有长连地址，startWebSocket 会失败
+			resp.Data, _ = json.Marshal(StartAppRespData{GameInfo: GameInfo{GameId: "game-1"}})
+		case PathAppHeartbeat:
+			beats.Add(1)
+		case PathAppEnd:
+			ended.Add(1)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	am := NewAppManagerWithOptions(AppOptions{
+		Name:              "test",
+		RoomIDCode:        "CODE",
+		Tasks:             task_manager.NewTaskManager(task_manager.Options{Name: "test"}),
+		Client:            NewClient(ClientOptions{BaseURL: srv.URL, AccessKey: "ak", SecretKey: "sk"}),
+		HeartbeatInterval: 10 * time.Millisecond,
+	})
+	if err := am.Start(); err == nil {
+		t.Fatal("期望启动失败")
+	}
+	if am.IsRunning() {
+		t.Error("启动失败后不应处于运行状态")
+	}
+	if got := ended.Load(); got != 1 {
+		t.Errorf("关闭场次 %d 次, want 1", got)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if got := beats.Load(); got != 0 {
+		t.Errorf("启动失败后仍发送了 %d 次心跳", got)
+	}
+}
@@ -0,0 +1,40 @@
+package bili
+
+import (
+	"fmt"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/recorder"
+)
+
+// StartReplay 以回放模式启动，不连接B站开放平台，把录制文件中的消息送入消息处理流程
+// speed 为回放倍速，1为实时，小于等于0时不等待；返回的通道在回放结束后关闭
+func (am *AppManager) StartReplay(path string, speed float64) (<-chan struct{}, error) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	if am.isRunning {
+		return nil, fmt.Errorf("应用已经在运行中")
+	}
+
+	logger.Info(fmt.Sprintf("正在以回放模式启动应用管理器: %s", path))
+
+	// 启动事件驱动任务处理器
+	am.startEventDrivenTaskProcessor()
+
+	// 启动事件流，回放时仍可以注入手动弹幕
+	am.stream.Start(am.ctx)
+
+	done := make(chan struct{})
+	am.wg.Add(1)
+	go func() {
+		defer am.wg.Done()
+		defer close(done)
+		if _, err := recorder.Replay(am.ctx, path, speed, am.handler.HandleMessage); err != nil {
+			logger.Error(fmt.Sprintf("回放录制文件失败: %v", err))
+		}
+	}()
+
+	am.isRunning = true
+	return done, nil
+}
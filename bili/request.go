@@ -1,6 +1,7 @@
 package bili
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/md5"
 	"crypto/sha256"
@@ -9,13 +10,9 @@ import (
 	"fmt"
 	"log"
 	"sort"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/CoffeeSwt/bilibili-tts-chat/config"
-
-	"github.com/monaco-io/request"
 )
 
 const (
@@ -72,33 +69,11 @@ type BaseResp struct {
 	Data      json.RawMessage `json:"data"`
 }
 
-// ApiRequest http request demo
-// 这是一个通用的HTTP请求方法，用于向B站开放平台发送POST请求
+// ApiRequest 向B站开放平台发送POST请求
+// 返回码不为0时不返回错误，需要调用方检查 resp.Code；新代码请使用 Client
 func ApiRequest(reqJson, requestUrl string) (resp BaseResp, err error) {
-	resp = BaseResp{}
-	header := &CommonHeader{
-		ContentType:       JsonType,
-		ContentAcceptType: JsonType,
-		Timestamp:         strconv.FormatInt(time.Now().Unix(), 10),
-		SignatureMethod:   HmacSha256,
-		SignatureVersion:  BiliVersion,
-		Authorization:     "",
-		Nonce:             strconv.FormatInt(time.Now().UnixNano(), 10), //用于幂等,记得替换
-		AccessKeyId:       GetAccessKey(),
-		ContentMD5:        Md5(reqJson),
-	}
-	header.Authorization = CreateSignature(header, GetAccessKeySecret())
-	cli := request.Client{
-		Method: "POST",
-		URL:    fmt.Sprintf("%s%s", config.OpenPlatformHttpHost, requestUrl),
-		Header: header.ToMap(),
-		String: reqJson,
-	}
-	cliResp := cli.Send().Scan(&resp)
-	if !cliResp.OK() {
-		err = fmt.Errorf("[error] req:%+v resp:%+v err:%+v", reqJson, resp, cliResp.Error())
-	}
-	return
+	client := NewClient(ClientOptions{MaxRetries: -1})
+	return client.send(context.Background(), requestUrl, []byte(reqJson))
 }
 
 // CreateSignature 生成Authorization加密串
@@ -2,6 +2,7 @@ package bili
 
 import (
 	"bytes"
+	"compress/zlib"
 	"context"
 	"encoding/binary"
 	"encoding/json"
@@ -15,14 +16,17 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/CoffeeSwt/bilibili-tts-chat/config"
+	"github.com/CoffeeSwt/bilibili-tts-chat/eventsource"
 	"github.com/CoffeeSwt/bilibili-tts-chat/handler"
 	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
 
+	"github.com/andybalholm/brotli"
 	"github.com/gorilla/websocket"
 )
 
 const (
-	MaxBodySize     = int32(1 << 11)
+	MaxBodySize     = int32(1 << 16)
 	CmdSize         = 4
 	PackSize        = 4
 	HeaderSize      = 2
@@ -48,6 +52,14 @@ const (
 	OP_AUTH_REPLY      = int32(8)
 )
 
+// 协议版本，决定消息体的编码方式
+const (
+	VER_NORMAL    = int16(0) // 消息体为原始JSON
+	VER_HEARTBEAT = int16(1) // 消息体为心跳数据
+	VER_ZLIB      = int16(2) // 消息体为zlib压缩的嵌套包
+	VER_BROTLI    = int16(3) // 消息体为brotli压缩的嵌套包
+)
+
 // ConnectionState represents the current state of the WebSocket connection
 type ConnectionState int
 
@@ -89,12 +101,36 @@ type ConnectionQuality struct {
 	AverageLatency     time.Duration
 	ConnectionUptime   time.Duration
 	ReconnectAttempts  int64
+	ActiveEndpoint     string           // 当前使用的长连节点
+	Endpoints          []EndpointHealth // 所有长连节点的健康状况
+}
+
+// WebsocketOptions 长连的重连参数
+type WebsocketOptions struct {
+	MaxReconnects int           // 最大重试次数
+	BaseDelay     time.Duration // 重连基础等待时间
+	MaxDelay      time.Duration // 重连最大等待时间
+	OnExhausted   func()        // 放弃重连后的回调，不能阻塞
+
+	MessageHandler *handler.MessageHandler // 消息处理器，为空时写入全局任务管理器
+	Stream         *eventsource.Stream     // 事件流，设置后消息写入事件流，与其他事件来源按到达顺序处理
+}
+
+// DefaultWebsocketOptions 从用户配置读取重连参数
+func DefaultWebsocketOptions() WebsocketOptions {
+	return WebsocketOptions{
+		MaxReconnects: config.GetWsMaxReconnects(),
+		BaseDelay:     config.GetWsReconnectBaseDelay(),
+		MaxDelay:      config.GetWsReconnectMaxDelay(),
+	}
 }
 
 type WebsocketClient struct {
 	// Connection management
 	conn       *websocket.Conn
+	connMutex  sync.Mutex // 保护 conn，并保证同一时间只有一个写入者
 	wsAddr     string
+	endpoints  *endpointPool
 	authBody   string
 	state      ConnectionState
 	stateMutex sync.RWMutex
@@ -105,6 +141,8 @@ type WebsocketClient struct {
 	dispather      map[int32]protoLogic
 	authed         bool
 	messageHandler *handler.MessageHandler
+	stream         *eventsource.Stream
+	readDone       chan struct{} // 当前连接的读取已结束
 
 	// Reconnection management
 	ctx            context.Context
@@ -113,6 +151,8 @@ type WebsocketClient struct {
 	maxReconnects  int
 	baseDelay      time.Duration
 	maxDelay       time.Duration
+	onExhausted    func()
+	closing        atomic.Bool
 
 	// Health monitoring
 	lastHeartbeat time.Time
@@ -155,8 +195,13 @@ var (
 	globalMutex           sync.Mutex
 )
 
-// StartWebsocket 启动长连
-func StartWebsocket(wsAddr, authBody string) (err error) {
+// StartWebsocket 启动全局长连
+// wsAddrs 为StartApp返回的全部长连地址，连接失败时会在这些节点间切换
+func StartWebsocket(wsAddrs []string, authBody string, opts WebsocketOptions) (err error) {
+	if len(wsAddrs) == 0 {
+		return fmt.Errorf("WebSocket链接为空")
+	}
+
 	globalMutex.Lock()
 	defer globalMutex.Unlock()
 
@@ -169,20 +214,41 @@ func StartWebsocket(wsAddr, authBody string) (err error) {
 		globalWebsocketClient = nil
 	}
 
+	wc, err := NewWebsocketClient(wsAddrs, authBody, opts)
+	if err != nil {
+		return err
+	}
+	globalWebsocketClient = wc
+	return nil
+}
+
+// NewWebsocketClient 创建并启动一个独立的长连，多直播间时每个直播间使用自己的长连
+func NewWebsocketClient(wsAddrs []string, authBody string, opts WebsocketOptions) (*WebsocketClient, error) {
+	if len(wsAddrs) == 0 {
+		return nil, fmt.Errorf("WebSocket链接为空")
+	}
+
+	messageHandler := opts.MessageHandler
+	if messageHandler == nil {
+		messageHandler = handler.NewMessageHandler(nil)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	wc := &WebsocketClient{
-		wsAddr:         wsAddr,
+		endpoints:      newEndpointPool(wsAddrs),
 		authBody:       authBody,
 		state:          StateDisconnected,
 		msgBuf:         make(chan *Proto, 1024),
 		dispather:      make(map[int32]protoLogic),
-		messageHandler: handler.NewMessageHandler(),
+		messageHandler: messageHandler,
+		stream:         opts.Stream,
 		ctx:            ctx,
 		cancel:         cancel,
-		maxReconnects:  10,
-		baseDelay:      time.Second,
-		maxDelay:       30 * time.Second,
+		maxReconnects:  opts.MaxReconnects,
+		baseDelay:      opts.BaseDelay,
+		maxDelay:       opts.MaxDelay,
+		onExhausted:    opts.OnExhausted,
 		shutdownChan:   make(chan struct{}),
 		doneChan:       make(chan struct{}),
 	}
@@ -198,8 +264,7 @@ func StartWebsocket(wsAddr, authBody string) (err error) {
 	// 启动健康监控
 	go wc.healthMonitor()
 
-	globalWebsocketClient = wc
-	return nil
+	return wc, nil
 }
 
 // StopWebsocket 停止全局WebSocket连接
@@ -218,6 +283,7 @@ func StopWebsocket() error {
 // Shutdown gracefully shuts down the WebSocket client
 func (wc *WebsocketClient) Shutdown() error {
 	logger.Info("正在关闭WebSocket客户端...")
+	wc.closing.Store(true)
 	wc.setState(StateShuttingDown)
 
 	// Signal shutdown to all goroutines
@@ -234,17 +300,19 @@ func (wc *WebsocketClient) Shutdown() error {
 	}
 
 	// Close connection gracefully
+	wc.connMutex.Lock()
 	if wc.conn != nil {
-		// Send close frame
-		wc.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
-
 		// Set close deadline
 		wc.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
 
+		// Send close frame
+		wc.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+
 		// Close the connection
 		wc.conn.Close()
 		wc.conn = nil
 	}
+	wc.connMutex.Unlock()
 
 	// Wait for goroutines to finish with timeout
 	done := make(chan struct{})
@@ -308,6 +376,7 @@ func (wc *WebsocketClient) connectionManager() {
 				if wc.reconnectCount >= wc.maxReconnects {
 					logger.Error(fmt.Sprintf("最大重试次数(%d)已达，停止重试", wc.maxReconnects))
 					wc.setState(StateShuttingDown)
+					wc.notifyExhausted()
 					return
 				}
 				wc.waitForReconnect()
@@ -319,8 +388,14 @@ func (wc *WebsocketClient) connectionManager() {
 			logger.Info(fmt.Sprintf("连接成功，已重试次数 %d 次", wc.reconnectCount))
 
 			// Start message processing
+			// readDone 在本次连接的读取结束后关闭，通知DoEvent退出，以便进入重连
+			readDone := make(chan struct{})
+			wc.readDone = readDone
 			wc.wg.Add(2)
-			go wc.ReadMsg()
+			go func() {
+				defer close(readDone)
+				wc.ReadMsg()
+			}()
 			go wc.DoEvent()
 
 			// Wait for connection to close or shutdown signal
@@ -336,6 +411,7 @@ func (wc *WebsocketClient) connectionManager() {
 				// 检查是否应该重连
 				if wc.getState() == StateShuttingDown {
 					logger.Info("关闭状态检测到，不再尝试重连")
+					wc.notifyExhausted()
 					return
 				}
 				logger.Info("准备进行重连...")
@@ -350,6 +426,14 @@ func (wc *WebsocketClient) connectionManager() {
 	}
 }
 
+// notifyExhausted 连接管理器主动放弃重连时通知上层，主动关闭时不通知
+func (wc *WebsocketClient) notifyExhausted() {
+	if wc.closing.Load() || wc.onExhausted == nil {
+		return
+	}
+	wc.onExhausted()
+}
+
 // updateConnectionQuality updates connection quality metrics
 func (wc *WebsocketClient) updateConnectionQuality(eventType string, err error) {
 	wc.qualityMutex.Lock()
@@ -364,11 +448,13 @@ func (wc *WebsocketClient) updateConnectionQuality(eventType string, err error)
 		atomic.AddInt64(&wc.quality.TotalConnections, 1)
 	case "connect_success":
 		atomic.AddInt64(&wc.quality.SuccessfulConnects, 1)
+		wc.endpoints.markSuccess(wc.wsAddr)
 		wc.quality.LastConnectTime = time.Now()
 		wc.connectionStart = time.Now()
 		atomic.StoreInt64(&wc.consecutiveErrors, 0)
 	case "connect_failed":
 		atomic.AddInt64(&wc.quality.FailedConnects, 1)
+		wc.endpoints.markFailure(wc.wsAddr)
 		atomic.AddInt64(&wc.consecutiveErrors, 1)
 	case "disconnect":
 		wc.quality.LastDisconnectTime = time.Now()
@@ -377,12 +463,15 @@ func (wc *WebsocketClient) updateConnectionQuality(eventType string, err error)
 		}
 	case "abnormal_closure":
 		atomic.AddInt64(&wc.quality.AbnormalClosures, 1)
+		wc.endpoints.markFailure(wc.wsAddr)
 		atomic.AddInt64(&wc.consecutiveErrors, 1)
 	case "timeout_error":
 		atomic.AddInt64(&wc.quality.TimeoutErrors, 1)
+		wc.endpoints.markFailure(wc.wsAddr)
 		atomic.AddInt64(&wc.consecutiveErrors, 1)
 	case "network_error":
 		atomic.AddInt64(&wc.quality.NetworkErrors, 1)
+		wc.endpoints.markFailure(wc.wsAddr)
 		atomic.AddInt64(&wc.consecutiveErrors, 1)
 	case "reconnect_attempt":
 		atomic.AddInt64(&wc.quality.ReconnectAttempts, 1)
@@ -402,6 +491,8 @@ func (wc *WebsocketClient) GetConnectionQuality() ConnectionQuality {
 
 	quality := wc.quality
 	quality.AverageLatency = wc.pingLatency
+	quality.ActiveEndpoint = wc.endpoints.active()
+	quality.Endpoints = wc.endpoints.snapshot()
 	if !wc.connectionStart.IsZero() && wc.getState() == StateConnected {
 		quality.ConnectionUptime = time.Since(wc.connectionStart)
 	}
@@ -412,6 +503,7 @@ func (wc *WebsocketClient) GetConnectionQuality() ConnectionQuality {
 func (wc *WebsocketClient) LogConnectionQuality() {
 	quality := wc.GetConnectionQuality()
 	logger.Info("连接质量指标:")
+	logger.Info(fmt.Sprintf("  当前节点: %s", quality.ActiveEndpoint))
 	logger.Info(fmt.Sprintf("  总连接次数: %d", quality.TotalConnections))
 	logger.Info(fmt.Sprintf("  成功连接次数: %d", quality.SuccessfulConnects))
 	logger.Info(fmt.Sprintf("  异常关闭次数: %d", quality.AbnormalClosures))
@@ -421,6 +513,13 @@ func (wc *WebsocketClient) LogConnectionQuality() {
 	logger.Info(fmt.Sprintf("  当前连接时长: %v", quality.ConnectionUptime))
 	logger.Info(fmt.Sprintf("  平均延迟: %v", quality.AverageLatency))
 	logger.Info(fmt.Sprintf("  连续错误次数: %d (最大: %d)", atomic.LoadInt64(&wc.consecutiveErrors), wc.maxConsecutiveErrors))
+	for _, ep := range quality.Endpoints {
+		logger.Info(fmt.Sprintf("  节点 %s: 近期失败 %d 次", ep.Addr, ep.RecentFailures))
+	}
+	if wc.messageHandler != nil {
+		stats := wc.messageHandler.GetStats()
+		logger.Info(fmt.Sprintf("  重复消息: %v 条 (已记录: %v)", stats["dedup_hits"], stats["dedup_size"]))
+	}
 }
 
 // isEOFError checks if the error is an EOF or connection closed error
@@ -601,32 +700,48 @@ func (wc *WebsocketClient) DoEvent() {
 	defer wc.wg.Done()
 	defer logger.Info("DoEvent  goroutine 退出")
 
+	readDone := wc.readDone
 	for {
 		select {
 		case <-wc.ctx.Done():
 			logger.Info("DoEvent: Context cancelled, stopping event processing")
 			return
-		case proto := <-wc.msgBuf:
-			if proto == nil {
-				continue
-			}
-
-			if logic, ok := wc.dispather[proto.Operation]; ok {
-				func() {
-					defer func() {
-						if r := recover(); r != nil {
-							logger.Error(fmt.Sprintf("Panic in event handler for operation %d: %v", proto.Operation, r))
-						}
-					}()
-					logic(proto)
-				}()
-			} else {
-				logger.Info(fmt.Sprintf("No handler for operation: %d", proto.Operation))
+		case <-readDone:
+			// 连接已断开，处理完缓冲区中剩余的消息后退出
+			for {
+				select {
+				case proto := <-wc.msgBuf:
+					wc.dispatch(proto)
+				default:
+					return
+				}
 			}
+		case proto := <-wc.msgBuf:
+			wc.dispatch(proto)
 		}
 	}
 }
 
+// dispatch 按操作类型分发一个包
+func (wc *WebsocketClient) dispatch(proto *Proto) {
+	if proto == nil {
+		return
+	}
+
+	if logic, ok := wc.dispather[proto.Operation]; ok {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error(fmt.Sprintf("Panic in event handler for operation %d: %v", proto.Operation, r))
+				}
+			}()
+			logic(proto)
+		}()
+	} else {
+		logger.Info(fmt.Sprintf("No handler for operation: %d", proto.Operation))
+	}
+}
+
 // healthMonitor monitors connection health and sends heartbeats
 func (wc *WebsocketClient) healthMonitor() {
 	// 根据官方协议要求，心跳频率为20秒
@@ -711,9 +826,6 @@ func (wc *WebsocketClient) sendHeartBeat() error {
 	if !wc.authed {
 		return fmt.Errorf("not authenticated")
 	}
-	if wc.conn == nil {
-		return fmt.Errorf("connection is nil")
-	}
 
 	msg := &Proto{}
 	msg.Operation = OP_HEARTBEAT
@@ -729,6 +841,8 @@ func (wc *WebsocketClient) sendHeartBeat() error {
 
 // sendPing 发送WebSocket ping帧来测试连接
 func (wc *WebsocketClient) sendPing() error {
+	wc.connMutex.Lock()
+	defer wc.connMutex.Unlock()
 	if wc.conn == nil {
 		return fmt.Errorf("connection is nil")
 	}
@@ -839,6 +953,9 @@ func (wc *WebsocketClient) getState() ConnectionState {
 // connect establishes a WebSocket connection
 func (wc *WebsocketClient) connect() error {
 	wc.setState(StateConnecting)
+	wc.qualityMutex.Lock()
+	wc.wsAddr = wc.endpoints.next()
+	wc.qualityMutex.Unlock()
 	wc.updateConnectionQuality("connect_attempt", nil)
 
 	logger.Info(fmt.Sprintf("尝试连接 %s (第 %d/%d 次)", wc.wsAddr, wc.reconnectCount+1, wc.maxReconnects))
@@ -849,7 +966,8 @@ func (wc *WebsocketClient) connect() error {
 		timeoutMultiplier = 5 // Cap at 5x
 	}
 
-	dialer := websocket.DefaultDialer
+	// 复制默认拨号器，多个长连同时连接时不能修改共享的 DefaultDialer
+	dialer := *websocket.DefaultDialer
 	dialer.HandshakeTimeout = time.Duration(timeoutMultiplier) * 15 * time.Second
 
 	conn, _, err := dialer.Dial(wc.wsAddr, nil)
@@ -870,14 +988,16 @@ func (wc *WebsocketClient) connect() error {
 		return fmt.Errorf("连接失败: %w", err)
 	}
 
+	wc.connMutex.Lock()
 	wc.conn = conn
+	wc.connMutex.Unlock()
 	wc.setState(StateConnected)
 	wc.updateConnectionQuality("connect_success", nil)
 	wc.lastHeartbeat = time.Now()
 	wc.lastPong = time.Now()
 
 	// Enhanced ping/pong handlers with latency measurement
-	wc.conn.SetPongHandler(func(appData string) error {
+	conn.SetPongHandler(func(appData string) error {
 		now := time.Now()
 		wc.lastPong = now
 
@@ -894,10 +1014,12 @@ func (wc *WebsocketClient) connect() error {
 	})
 
 	// Enhanced ping handler
-	wc.conn.SetPingHandler(func(appData string) error {
+	conn.SetPingHandler(func(appData string) error {
 		logger.Info("收到服务器 Ping，发送 Pong")
-		wc.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
-		return wc.conn.WriteMessage(websocket.PongMessage, []byte(appData))
+		wc.connMutex.Lock()
+		defer wc.connMutex.Unlock()
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		return conn.WriteMessage(websocket.PongMessage, []byte(appData))
 	})
 
 	logger.Info(fmt.Sprintf("成功连接到 %s", wc.wsAddr))
@@ -916,13 +1038,23 @@ func (wc *WebsocketClient) connect() error {
 func (wc *WebsocketClient) disconnect() {
 	wc.setState(StateDisconnected)
 	wc.updateConnectionQuality("disconnect", nil)
+	wc.connMutex.Lock()
 	if wc.conn != nil {
 		wc.conn.Close()
 		wc.conn = nil
 	}
+	wc.connMutex.Unlock()
 	wc.authed = false
 }
 
+// getConn 返回当前连接，断开后为空
+// 读取不需要持有锁，连接被其他goroutine关闭时 ReadMessage 返回错误
+func (wc *WebsocketClient) getConn() *websocket.Conn {
+	wc.connMutex.Lock()
+	defer wc.connMutex.Unlock()
+	return wc.conn
+}
+
 // ReadMsg 读取长连信息
 func (wc *WebsocketClient) ReadMsg() {
 	defer wc.wg.Done()
@@ -938,15 +1070,16 @@ func (wc *WebsocketClient) ReadMsg() {
 			logger.Info("ReadMsg: 上下文取消，停止消息读取")
 			return
 		default:
-			if wc.conn == nil {
+			conn := wc.getConn()
+			if conn == nil {
 				logger.Info("ReadMsg: 连接为空，停止消息读取")
 				return
 			}
 
 			// 设置更长的读取超时时间 (120秒)
-			// wc.conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+			// conn.SetReadDeadline(time.Now().Add(10 * time.Second))
 
-			_, data, err := wc.conn.ReadMessage()
+			_, data, err := conn.ReadMessage()
 			if err != nil {
 				// 检查是否为超时错误
 				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
@@ -978,8 +1111,7 @@ func (wc *WebsocketClient) ReadMsg() {
 			wc.lastPong = time.Now()
 
 			// Process the message
-			proto := wc.unpack(data)
-			if proto != nil {
+			for _, proto := range wc.unpack(data) {
 				select {
 				case wc.msgBuf <- proto:
 					// Message sent successfully
@@ -994,49 +1126,125 @@ func (wc *WebsocketClient) ReadMsg() {
 	}
 }
 
-// unpack unpacks the raw message data into a Proto struct
-func (wc *WebsocketClient) unpack(buf []byte) *Proto {
+// unpack 解析一个WebSocket帧
+func (wc *WebsocketClient) unpack(buf []byte) []*Proto {
+	return UnpackFrame(buf)
+}
+
+// UnpackFrame 解析一个WebSocket帧，帧内可能包含多个首尾相连的包
+// 压缩包（zlib/brotli）会被解压并解析，内层的消息体汇总到外层包的 BodyMuti 中
+// 只解压一层，内层再出现的压缩包会被丢弃，避免嵌套压缩的数据无限展开
+func UnpackFrame(buf []byte) []*Proto {
+	return unpackFrame(buf, false)
+}
+
+// unpackFrame 解析一个WebSocket帧，nested 为true时表示已在解压后的数据中
+func unpackFrame(buf []byte, nested bool) []*Proto {
+	var protos []*Proto
+	for len(buf) > 0 {
+		proto, rest, err := readPacket(buf)
+		if err != nil {
+			logger.Info(fmt.Sprintf("解析数据包失败: %v", err))
+			break
+		}
+		buf = rest
+
+		switch proto.Version {
+		case VER_ZLIB, VER_BROTLI:
+			if nested {
+				logger.Info(fmt.Sprintf("丢弃嵌套的压缩包 (ver: %d)", proto.Version))
+				continue
+			}
+			inner, err := decompressBody(proto.Version, proto.Body)
+			if err != nil {
+				logger.Info(fmt.Sprintf("解压数据包失败 (ver: %d): %v", proto.Version, err))
+				continue
+			}
+			proto.BodyMuti = nil
+			for _, p := range unpackFrame(inner, true) {
+				if p.Operation == proto.Operation {
+					proto.BodyMuti = append(proto.BodyMuti, p.BodyMuti...)
+				} else {
+					protos = append(protos, p)
+				}
+			}
+			if len(proto.BodyMuti) == 0 {
+				continue
+			}
+			proto.Body = proto.BodyMuti[0]
+		default:
+			proto.BodyMuti = [][]byte{proto.Body}
+		}
+		protos = append(protos, proto)
+	}
+	return protos
+}
+
+// readPacket 从缓冲区头部读取一个完整的包，返回包和剩余的数据
+func readPacket(buf []byte) (*Proto, []byte, error) {
 	if len(buf) < RawHeaderSize {
-		logger.Info(fmt.Sprintf("消息太短: %d 字节", len(buf)))
-		return nil
+		return nil, nil, fmt.Errorf("消息太短: %d 字节", len(buf))
 	}
 
-	retProto := &Proto{}
-	retProto.PacketLength = int32(binary.BigEndian.Uint32(buf[PackOffset:HeaderOffset]))
-	retProto.HeaderLength = int16(binary.BigEndian.Uint16(buf[HeaderOffset:VerOffset]))
-	retProto.Version = int16(binary.BigEndian.Uint16(buf[VerOffset:OperationOffset]))
-	retProto.Operation = int32(binary.BigEndian.Uint32(buf[OperationOffset:SeqIdOffset]))
-	retProto.SequenceId = int32(binary.BigEndian.Uint32(buf[SeqIdOffset:]))
+	p := &Proto{}
+	p.PacketLength = int32(binary.BigEndian.Uint32(buf[PackOffset:HeaderOffset]))
+	p.HeaderLength = int16(binary.BigEndian.Uint16(buf[HeaderOffset:VerOffset]))
+	p.Version = int16(binary.BigEndian.Uint16(buf[VerOffset:OperationOffset]))
+	p.Operation = int32(binary.BigEndian.Uint32(buf[OperationOffset:SeqIdOffset]))
+	p.SequenceId = int32(binary.BigEndian.Uint32(buf[SeqIdOffset:HeartbeatOffset]))
 
-	if retProto.PacketLength < 0 || retProto.PacketLength > MaxPackSize {
-		logger.Info(fmt.Sprintf("无效的包长度: %d", retProto.PacketLength))
-		return nil
+	if p.PacketLength < RawHeaderSize || p.PacketLength > MaxPackSize {
+		return nil, nil, fmt.Errorf("无效的包长度: %d", p.PacketLength)
 	}
-	if retProto.HeaderLength != RawHeaderSize {
-		logger.Info(fmt.Sprintf("无效的头长度: %d", retProto.HeaderLength))
-		return nil
+	if p.HeaderLength != RawHeaderSize {
+		return nil, nil, fmt.Errorf("无效的头长度: %d", p.HeaderLength)
 	}
-
-	if bodyLen := int(retProto.PacketLength - int32(retProto.HeaderLength)); bodyLen > 0 {
-		if len(buf) < int(retProto.PacketLength) {
-			logger.Info(fmt.Sprintf("缓冲区太短，无法容纳完整包:  got %d, need %d", len(buf), retProto.PacketLength))
-			return nil
-		}
-		retProto.Body = buf[retProto.HeaderLength:retProto.PacketLength]
-	} else {
-		retProto.Body = []byte{}
+	if len(buf) < int(p.PacketLength) {
+		return nil, nil, fmt.Errorf("缓冲区太短，无法容纳完整包: got %d, need %d", len(buf), p.PacketLength)
 	}
 
-	retProto.BodyMuti = [][]byte{retProto.Body}
-	if len(retProto.BodyMuti) > 0 {
-		retProto.Body = retProto.BodyMuti[0]
+	p.Body = buf[p.HeaderLength:p.PacketLength]
+	return p, buf[p.PacketLength:], nil
+}
+
+// decompressBody 按协议版本解压消息体
+func decompressBody(version int16, body []byte) ([]byte, error) {
+	var r io.Reader
+	switch version {
+	case VER_ZLIB:
+		zr, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	case VER_BROTLI:
+		r = brotli.NewReader(bytes.NewReader(body))
+	default:
+		return body, nil
 	}
+	return io.ReadAll(io.LimitReader(r, int64(MaxPackSize)*64))
+}
 
-	return retProto
+// Pack 按协议格式编码为二进制包
+func (p *Proto) Pack() []byte {
+	dataBuff := &bytes.Buffer{}
+	packLen := int32(RawHeaderSize + len(p.Body))
+	p.PacketLength = packLen
+	p.HeaderLength = RawHeaderSize
+	binary.Write(dataBuff, binary.BigEndian, packLen)
+	binary.Write(dataBuff, binary.BigEndian, int16(RawHeaderSize))
+	binary.Write(dataBuff, binary.BigEndian, p.Version)
+	binary.Write(dataBuff, binary.BigEndian, p.Operation)
+	binary.Write(dataBuff, binary.BigEndian, p.SequenceId)
+	binary.Write(dataBuff, binary.BigEndian, p.Body)
+	return dataBuff.Bytes()
 }
 
 // sendMsg 发送信息
 func (wc *WebsocketClient) sendMsg(msg *Proto) (err error) {
+	wc.connMutex.Lock()
+	defer wc.connMutex.Unlock()
 	if wc.conn == nil {
 		return fmt.Errorf("连接为空")
 	}
@@ -1044,20 +1252,10 @@ func (wc *WebsocketClient) sendMsg(msg *Proto) (err error) {
 	wc.sequenceId++
 	msg.SequenceId = wc.sequenceId
 
-	dataBuff := &bytes.Buffer{}
-	packLen := int32(RawHeaderSize + len(msg.Body))
-	msg.HeaderLength = RawHeaderSize
-	binary.Write(dataBuff, binary.BigEndian, packLen)
-	binary.Write(dataBuff, binary.BigEndian, int16(RawHeaderSize))
-	binary.Write(dataBuff, binary.BigEndian, msg.Version)
-	binary.Write(dataBuff, binary.BigEndian, msg.Operation)
-	binary.Write(dataBuff, binary.BigEndian, msg.SequenceId)
-	binary.Write(dataBuff, binary.BigEndian, msg.Body)
-
 	// Set write deadline
 	wc.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
 
-	err = wc.conn.WriteMessage(websocket.BinaryMessage, dataBuff.Bytes())
+	err = wc.conn.WriteMessage(websocket.BinaryMessage, msg.Pack())
 	if err != nil {
 		logger.Error(fmt.Sprintf("发送消息失败 (seq: %d, op: %d): %v", msg.SequenceId, msg.Operation, err))
 		return
@@ -1092,6 +1290,14 @@ func (wc *WebsocketClient) msgResp(msg *Proto) (err error) {
 		// 记录原始消息（可选，用于调试）
 		logger.Info("收到原始消息", "长度", len(cmd))
 
+		// 设置了事件流时写入事件流，由事件流统一交给消息处理器
+		if wc.stream != nil {
+			if emitErr := wc.stream.Emit(eventsource.SourceWebsocket, cmd); emitErr != nil {
+				logger.Error(fmt.Sprintf("消息写入事件流失败: %v", emitErr))
+			}
+			continue
+		}
+
 		// 使用消息处理器处理消息
 		if wc.messageHandler != nil {
 			if handleErr := wc.messageHandler.HandleMessage(cmd); handleErr != nil {
@@ -0,0 +1,204 @@
+package bili
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"testing"
+)
+
+// 以下帧按开放平台长连接的协议格式手工构造（十六进制），消息体做了简化
+const (
+	dmBodyA = `{"cmd":"LIVE_OPEN_PLATFORM_DM","data":{"uname":"a","msg":"1"}}`
+	dmBodyB = `{"cmd":"LIVE_OPEN_PLATFORM_DM","data":{"uname":"b","msg":"2"}}`
+
+	// 两个首尾相连的普通弹幕包
+	frameMultiPacket = "0000004e0010000000000005000000007b22636d64223a224c4956455f4f50454e5f504c4154464f524d5f444d222c2264617461223a7b22756e616d65223a2261222c226d7367223a2231227d7d" +
+		"0000004e0010000000000005000000007b22636d64223a224c4956455f4f50454e5f504c4154464f524d5f444d222c2264617461223a7b22756e616d65223a2262222c226d7367223a2232227d7d"
+	// zlib压缩的两个弹幕包
+	frameZlib = "0000006c001000020000000500000000789c626060f06310600001561051ad949c9ba264a5e4e319e61aef1fe0ea171fe0e318e2e61fe41befe2aba4a394925892a86455ad549a97989baa64a594a8a4a3945b9cae64a564a8545b4ba9694970d38c946a6b01030072762545"
+	// brotli压缩的两个弹幕包
+	frameBrotli = "000000690010000300000005000000001b9b0000c4a86da19d9037e3f11aae5534c96b620e36e00003f504071c4c77b891053b35c330392331283a3f58ce150469d47b43517af950a656eb177536b819648c2f87823cf85fd3b98160828cf37b80c000a545e698a014"
+	// zlib压缩，内层为 弹幕、心跳回复、弹幕
+	frameMixedOp = "0000007e001000020000000500000000789c626060f06310600001561051ad949c9ba264a5e4e319e61aef1fe0ea171fe0e318e2e61fe41befe2aba4a394925892a86455ad549a97989baa64a594a8a4a3945b9cae64a564a8545bcbc0c020c220c0c0c8c0c0c00c328d81818191521b92e0361829d5d6020600f618256e"
+)
+
+// wantProto 描述期望解析出的一个包
+type wantProto struct {
+	op     int32
+	bodies []string
+}
+
+func TestUnpackFrame(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame string
+		want  []wantProto
+	}{
+		{
+			name:  "多包帧",
+			frame: frameMultiPacket,
+			want: []wantProto{
+				{op: OP_SEND_SMS_REPLY, bodies: []string{dmBodyA}},
+				{op: OP_SEND_SMS_REPLY, bodies: []string{dmBodyB}},
+			},
+		},
+		{
+			name:  "zlib消息体",
+			frame: frameZlib,
+			want: []wantProto{
+				{op: OP_SEND_SMS_REPLY, bodies: []string{dmBodyA, dmBodyB}},
+			},
+		},
+		{
+			name:  "brotli消息体",
+			frame: frameBrotli,
+			want: []wantProto{
+				{op: OP_SEND_SMS_REPLY, bodies: []string{dmBodyA, dmBodyB}},
+			},
+		},
+		{
+			name:  "嵌套包含不同操作",
+			frame: frameMixedOp,
+			want: []wantProto{
+				{op: OP_HEARTBEAT_REPLY, bodies: []string{"\x00\x00\x00\x01"}},
+				{op: OP_SEND_SMS_REPLY, bodies: []string{dmBodyA, dmBodyB}},
+			},
+		},
+		{
+			name:  "只解压一层",
+			frame: frameNested(),
+			want: []wantProto{
+				{op: OP_SEND_SMS_REPLY, bodies: []string{dmBodyA}},
+			},
+		},
+		{
+			name:  "截断的输入",
+			frame: frameMultiPacket[:len(frameMultiPacket)-20],
+			want: []wantProto{
+				{op: OP_SEND_SMS_REPLY, bodies: []string{dmBodyA}},
+			},
+		},
+		{
+			name:  "头长度错误",
+			frame: "0000004e0012" + frameMultiPacket[12:],
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, err := hex.DecodeString(tt.frame)
+			if err != nil {
+				t.Fatalf("无效的测试帧: %v", err)
+			}
+
+			got := UnpackFrame(buf)
+			if len(got) != len(tt.want) {
+				t.Fatalf("包数量 = %d, want %d", len(got), len(tt.want))
+			}
+			for i, w := range tt.want {
+				if got[i].Operation != w.op {
+					t.Errorf("包[%d] operation = %d, want %d", i, got[i].Operation, w.op)
+				}
+				if len(got[i].BodyMuti) != len(w.bodies) {
+					t.Fatalf("包[%d] 消息数 = %d, want %d", i, len(got[i].BodyMuti), len(w.bodies))
+				}
+				for j, body := range w.bodies {
+					if string(got[i].BodyMuti[j]) != body {
+						t.Errorf("包[%d] 消息[%d] = %q, want %q", i, j, got[i].BodyMuti[j], body)
+					}
+				}
+				if string(got[i].Body) != w.bodies[0] {
+					t.Errorf("包[%d] Body = %q, want %q", i, got[i].Body, w.bodies[0])
+				}
+			}
+		})
+	}
+}
+
+// frameNested 构造一个zlib压缩包，内层为一个普通弹幕包和一个再次压缩的 frameZlib
+func frameNested() string {
+	plain, _ := hex.DecodeString(frameMultiPacket[:len(frameMultiPacket)/2])
+	inner, _ := hex.DecodeString(frameZlib)
+
+	var body bytes.Buffer
+	zw := zlib.NewWriter(&body)
+	zw.Write(plain)
+	zw.Write(inner)
+	zw.Close()
+
+	p := &Proto{Version: VER_ZLIB, Operation: OP_SEND_SMS_REPLY, Body: body.Bytes()}
+	return hex.EncodeToString(p.Pack())
+}
+
+func TestReadPacket(t *testing.T) {
+	frame, _ := hex.DecodeString(frameMultiPacket)
+
+	tests := []struct {
+		name     string
+		buf      []byte
+		wantErr  bool
+		wantRest int
+	}{
+		{name: "完整包", buf: frame, wantRest: len(frame) / 2},
+		{name: "不足一个头", buf: frame[:RawHeaderSize-1], wantErr: true},
+		{name: "包体截断", buf: frame[:RawHeaderSize+4], wantErr: true},
+		{name: "包长度小于头", buf: append([]byte{0, 0, 0, 8}, frame[PackSize:]...), wantErr: true},
+		{name: "头长度错误", buf: append(append([]byte{}, frame[:HeaderOffset]...), append([]byte{0, 0x12}, frame[VerOffset:]...)...), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, rest, err := readPacket(tt.buf)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("期望错误，got 包 %+v", p)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readPacket: %v", err)
+			}
+			if len(rest) != tt.wantRest {
+				t.Errorf("剩余 %d 字节, want %d", len(rest), tt.wantRest)
+			}
+			if string(p.Body) != dmBodyA {
+				t.Errorf("Body = %q, want %q", p.Body, dmBodyA)
+			}
+		})
+	}
+}
+
+func TestDecompressBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int16
+		frame   string
+		wantErr bool
+	}{
+		{name: "zlib", version: VER_ZLIB, frame: frameZlib},
+		{name: "brotli", version: VER_BROTLI, frame: frameBrotli},
+		{name: "版本与编码不符", version: VER_ZLIB, frame: frameBrotli, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf, _ := hex.DecodeString(tt.frame)
+			got, err := decompressBody(tt.version, buf[RawHeaderSize:])
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("期望解压失败")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decompressBody: %v", err)
+			}
+			protos := UnpackFrame(got)
+			if len(protos) != 2 {
+				t.Fatalf("解压后包数量 = %d, want 2", len(protos))
+			}
+		})
+	}
+}
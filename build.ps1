@@ -134,6 +134,7 @@ $configFiles = @(
     # .env is not copied because it's injected into the binary
     @{Source = "user.example.json"; Target = "user.json"}
     @{Source = "voices.json"; Target = "voices.json"}
+    @{Source = "reply_template\reply_templates.yaml"; Target = "reply_templates.yaml"}
 )
 
 foreach ($config in $configFiles) {
@@ -0,0 +1,134 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/response"
+	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
+	"github.com/CoffeeSwt/bilibili-tts-chat/user"
+)
+
+// defaultMuteMinutes 禁言没有指定时间时的分钟数
+const defaultMuteMinutes = 10
+
+// registerAdminCommands 注册房管指令，房管和主播可以使用，回复优先播报
+func registerAdminCommands(r *Router) {
+	admin := []*Command{
+		{Name: "跳过", Description: "停止正在播放的语音", Handler: handleSkip},
+		{Name: "清空队列", Description: "丢弃所有还没播报的内容", Handler: handleClearQueue},
+		{Name: "暂停播报", Description: "当前这句播完后暂停播报", Handler: handlePause},
+		{Name: "继续播报", Aliases: []string{"恢复播报"}, Description: "恢复播报", Handler: handleResume},
+		{Name: "切换AI回复", Description: "开启或关闭AI回复", Handler: handleToggleLLM},
+		{Name: "音量", Params: "1到100", Description: "调整播报音量", Accept: isNumber, Handler: handleVolume},
+		// 用户名可能是任意文字，需要和指令名用空格分开
+		{Name: "禁言", Params: "用户名 分钟数", Description: "禁言用户，期间不播报其弹幕，默认10分钟", Accept: never, Handler: handleMute},
+		{Name: "解除禁言", Params: "用户名", Description: "提前解除禁言", Accept: never, Handler: handleUnmute},
+	}
+	for _, cmd := range admin {
+		cmd.Permission = PermAdmin
+		r.MustRegister(cmd)
+	}
+}
+
+// isNumber 参数是否为一个整数
+func isNumber(args Args) bool {
+	_, err := strconv.Atoi(args.Raw)
+	return err == nil
+}
+
+// never 参数必须和指令名用空格分开
+func never(Args) bool {
+	return false
+}
+
+// reply 播报指令的回复，当前这句播完后优先播报，不受清空影响
+func reply(tm *task_manager.TaskManager, msg *response.DanmakuMessage, text string) {
+	tm.Announce(text, user.GetUserVoice(msg.Data.UName))
+}
+
+func handleSkip(tm *task_manager.TaskManager, msg *response.DanmakuMessage, _ Args) error {
+	if tm.Skip() {
+		reply(tm, msg, "已跳过当前播报")
+	} else {
+		reply(tm, msg, "当前没有正在播放的语音")
+	}
+	return nil
+}
+
+func handleClearQueue(tm *task_manager.TaskManager, msg *response.DanmakuMessage, _ Args) error {
+	cleared := tm.ClearQueue()
+	reply(tm, msg, fmt.Sprintf("已清空%d条待播报内容", cleared))
+	return nil
+}
+
+// handlePause 暂停播报，回复也会等到恢复后才播报，所以暂停时不回复
+func handlePause(tm *task_manager.TaskManager, msg *response.DanmakuMessage, _ Args) error {
+	tm.Pause()
+	logger.Info(fmt.Sprintf("[房管指令] %s 暂停了播报", msg.Data.UName))
+	return nil
+}
+
+func handleResume(tm *task_manager.TaskManager, msg *response.DanmakuMessage, _ Args) error {
+	tm.Resume()
+	reply(tm, msg, "播报已恢复")
+	return nil
+}
+
+func handleVolume(tm *task_manager.TaskManager, msg *response.DanmakuMessage, args Args) error {
+	volume, err := args.Int(0, 0)
+	if err != nil || volume < 1 || volume > 100 {
+		reply(tm, msg, "音量需要是1到100之间的数字")
+		return nil
+	}
+	if err := tm.SetVolume(volume); err != nil {
+		return fmt.Errorf("保存音量失败: %v", err)
+	}
+	reply(tm, msg, fmt.Sprintf("音量已调整为%d", volume))
+	return nil
+}
+
+// handleMute 禁言用户，格式为 "禁言 用户名 分钟数"，分钟数可以省略
+func handleMute(tm *task_manager.TaskManager, msg *response.DanmakuMessage, args Args) error {
+	uname := args.Get(0)
+	if uname == "" {
+		reply(tm, msg, "请在禁言后面加上用户名和分钟数")
+		return nil
+	}
+	minutes, err := args.Int(1, defaultMuteMinutes)
+	if err != nil || minutes <= 0 {
+		reply(tm, msg, "禁言时间需要是大于0的分钟数")
+		return nil
+	}
+	tm.Mutes().Mute(uname, time.Duration(minutes)*time.Minute)
+	logger.Info(fmt.Sprintf("[房管指令] 用户 %s 被禁言 %d 分钟", uname, minutes))
+	reply(tm, msg, fmt.Sprintf("已禁言%s %d分钟", uname, minutes))
+	return nil
+}
+
+// handleUnmute 解除用户的禁言
+func handleUnmute(tm *task_manager.TaskManager, msg *response.DanmakuMessage, args Args) error {
+	uname := args.Raw
+	if tm.Mutes().Unmute(uname) {
+		reply(tm, msg, fmt.Sprintf("已解除%s的禁言", uname))
+	} else {
+		reply(tm, msg, fmt.Sprintf("%s没有被禁言", uname))
+	}
+	return nil
+}
+
+// handleToggleLLM 开启或关闭本直播间的LLM回复，单直播间时保存到 user.json
+func handleToggleLLM(tm *task_manager.TaskManager, msg *response.DanmakuMessage, _ Args) error {
+	use := !tm.UseLLMReply()
+	if err := tm.SetUseLLMReply(use); err != nil {
+		return fmt.Errorf("保存LLM回复设置失败: %v", err)
+	}
+	if use {
+		reply(tm, msg, "AI回复已开启")
+	} else {
+		reply(tm, msg, "AI回复已关闭")
+	}
+	return nil
+}
@@ -2,7 +2,8 @@ package command
 
 import (
 	"fmt"
-	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/CoffeeSwt/bilibili-tts-chat/config"
 	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
@@ -11,18 +12,79 @@ import (
 	"github.com/CoffeeSwt/bilibili-tts-chat/user"
 )
 
-type Handler func(msg *response.DanmakuMessage, arg string) error
+// Default 内置指令的路由
+var Default = newDefaultRouter()
 
-var Exact = map[string]Handler{
-	"我的音色": handleQueryVoice,
-	"换音色":  handleRandomSwitchVoice,
+// newDefaultRouter 创建指令路由并注册内置指令
+func newDefaultRouter() *Router {
+	r := NewRouter()
+	r.MustRegister(&Command{
+		Name:         "我的音色",
+		Description:  "查询自己当前的播报音色",
+		UserCooldown: 10 * time.Second,
+		Handler:      handleQueryVoice,
+	})
+	r.MustRegister(&Command{
+		Name:         "换音色",
+		Aliases:      []string{"随机音色"},
+		Description:  "随机切换自己的播报音色",
+		UserCooldown: 10 * time.Second,
+		Handler:      handleRandomSwitchVoice,
+	})
+	r.MustRegister(&Command{
+		Name:        "换",
+		Params:      "音色名",
+		Description: "切换到指定的播报音色，支持拼音首字母、打错字、男声女声和名称中的字，如 换湾湾小何、换wwxh、换 萌女声",
+		// 紧跟的音色名找不到时按普通弹幕处理，避免 "换个游戏吧" 这类弹幕被当作指令
+		Accept:       acceptVoiceQuery,
+		UserCooldown: 10 * time.Second,
+		Handler:      handleSwitchVoiceByName,
+	})
+	r.MustRegister(&Command{
+		Name:             "试听",
+		Params:           "音色名",
+		Description:      "用指定的音色播报一句示例，不会切换自己的音色",
+		Accept:           acceptVoiceQuery,
+		UserCooldownFunc: config.GetVoicePreviewCooldown,
+		Handler:          handlePreviewVoice,
+	})
+	r.MustRegister(&Command{
+		Name:           "音色列表",
+		Params:         "页码或标签",
+		Description:    "播报一页音色名称，可以按男声、女声或名称中的字筛选，如 音色列表 2、音色列表 萌",
+		Accept:         isNumber,
+		UserCooldown:   30 * time.Second,
+		GlobalCooldown: 10 * time.Second,
+		Handler:        handleListVoices,
+	})
+	r.MustRegister(&Command{
+		Name:         "语速",
+		Params:       "-50到100或正常",
+		Description:  "调整自己弹幕的播报语速，在全局语速上增减，如 语速 +20、语速 正常，不带参数时查询当前语速",
+		Accept:       acceptSpeechRate,
+		UserCooldown: 10 * time.Second,
+		Handler:      handleSpeechRate,
+	})
+	r.MustRegister(&Command{
+		Name:         "我的音量",
+		Params:       "-50到100或正常",
+		Description:  "调整自己弹幕的播报音量，在全局音量上增减，如 我的音量 -20、我的音量 正常，不带参数时查询当前音量",
+		Accept:       acceptUserVolume,
+		UserCooldown: 10 * time.Second,
+		Handler:      handleUserVolume,
+	})
+	registerAdminCommands(r)
+	return r
 }
 
-var Prefix = map[string]Handler{
-	"换": handleSwitchVoiceByName,
+// say 把指令的回复加入播报队列，使用发送者的音色
+func say(tm *task_manager.TaskManager, msg *response.DanmakuMessage, text string) {
+	if err := tm.AddText(text, task_manager.TextTypeCommand, user.GetUserVoice(msg.Data.UName)); err != nil {
+		logger.Error(fmt.Sprintf("[DanmakuHandler] 添加指令回复到任务管理器失败: %v", err))
+	}
 }
 
-func handleQueryVoice(msg *response.DanmakuMessage, _ string) error {
+func handleQueryVoice(tm *task_manager.TaskManager, msg *response.DanmakuMessage, _ Args) error {
 	currentVoice := user.GetUserVoice(msg.Data.UName)
 	var queryMessage string
 	if currentVoice != nil {
@@ -34,50 +96,66 @@ func handleQueryVoice(msg *response.DanmakuMessage, _ string) error {
 		currentVoice = user.GetUserVoice(msg.Data.UName)
 	}
 	user.UpdateUserActivity(msg.Data.UName)
-	if err := task_manager.AddText(queryMessage, task_manager.TextTypeCommand, currentVoice); err != nil {
+	if err := tm.AddText(queryMessage, task_manager.TextTypeCommand, currentVoice); err != nil {
 		logger.Error(fmt.Sprintf("[DanmakuHandler] 添加音色查询结果到任务管理器失败: %v", err))
 	}
 	return nil
 }
 
-func handleRandomSwitchVoice(msg *response.DanmakuMessage, _ string) error {
-	v := config.GetRandomVoice()
+func handleRandomSwitchVoice(tm *task_manager.TaskManager, msg *response.DanmakuMessage, _ Args) error {
+	v := config.RandomVoiceFor(supporterOf(msg))
 	switchMessage := fmt.Sprintf("%s 的播报音色已随机切换为 %s", msg.Data.UName, v.Name)
 	logger.Info(fmt.Sprintf("[DanmakuHandler] 用户 %s 随机切换音色为: %s", msg.Data.UName, v.Name))
 	user.SetUserVoice(msg.Data.UName, v.VoiceType)
 	user.UpdateUserActivity(msg.Data.UName)
-	if err := task_manager.AddText(switchMessage, task_manager.TextTypeCommand, v); err != nil {
+	if err := tm.AddText(switchMessage, task_manager.TextTypeCommand, user.GetUserVoice(msg.Data.UName)); err != nil {
 		logger.Error(fmt.Sprintf("[DanmakuHandler] 添加事件到任务管理器失败: %v", err))
 	}
 	return nil
 }
 
-func handleSwitchVoiceByName(msg *response.DanmakuMessage, arg string) error {
-	var v *config.Voice
-	var switchMessage string
-	voiceName := strings.TrimSpace(arg)
-	if voiceName != "" {
-		targetVoice := config.GetVoiceByName(voiceName)
-		if targetVoice != nil {
-			v = targetVoice
-			switchMessage = fmt.Sprintf("%s 的播报音色已切换为 %s", msg.Data.UName, v.Name)
-			logger.Info(fmt.Sprintf("[DanmakuHandler] 用户 %s 指定切换音色为: %s", msg.Data.UName, v.Name))
-		} else {
-			v = config.GetRandomVoice()
-			switchMessage = fmt.Sprintf("%s 的播报音色已切换为 %s", msg.Data.UName, v.Name)
-			logger.Info(fmt.Sprintf("[DanmakuHandler] 用户 %s 指定的音色 \"%s\" 不存在，随机切换为: %s", msg.Data.UName, voiceName, v.Name))
-		}
-	} else {
-		v = config.GetRandomVoice()
-		switchMessage = fmt.Sprintf("%s 的播报音色已切换为 %s", msg.Data.UName, v.Name)
-		logger.Info(fmt.Sprintf("[DanmakuHandler] 用户 %s 指定音色不存在，随机切换为: %s", msg.Data.UName, v.Name))
+func handleSwitchVoiceByName(tm *task_manager.TaskManager, msg *response.DanmakuMessage, args Args) error {
+	if args.Raw == "" {
+		return handleRandomSwitchVoice(tm, msg, args)
 	}
-	if v != nil {
+
+	res := config.SearchVoiceFor(args.Raw, supporterOf(msg))
+	switch {
+	case res.Voice != nil:
+		v := res.Voice
+		if !checkVoiceAvailable(tm, msg, v) {
+			return nil
+		}
+		logger.Info(fmt.Sprintf("[DanmakuHandler] 用户 %s 按%s \"%s\" 切换音色为: %s", msg.Data.UName, res.Match, args.Raw, v.Name))
 		user.SetUserVoice(msg.Data.UName, v.VoiceType)
 		user.UpdateUserActivity(msg.Data.UName)
-		if err := task_manager.AddText(switchMessage, task_manager.TextTypeCommand, v); err != nil {
+		if err := tm.AddText(fmt.Sprintf("%s 的播报音色已切换为 %s", msg.Data.UName, v.Name), task_manager.TextTypeCommand, user.GetUserVoice(msg.Data.UName)); err != nil {
 			logger.Error(fmt.Sprintf("[DanmakuHandler] 添加事件到任务管理器失败: %v", err))
 		}
+	case len(res.Candidates) > 0:
+		names := voiceNames(res.Candidates)
+		logger.Info(fmt.Sprintf("[DanmakuHandler] 用户 %s 指定的音色 \"%s\" 有多个相近的音色: %s", msg.Data.UName, args.Raw, names))
+		say(tm, msg, fmt.Sprintf("%s，你是不是想找%s", msg.Data.UName, names))
+	default:
+		logger.Info(fmt.Sprintf("[DanmakuHandler] 用户 %s 指定的音色 \"%s\" 不存在", msg.Data.UName, args.Raw))
+		say(tm, msg, fmt.Sprintf("%s，没有找到音色%s", msg.Data.UName, args.Raw))
 	}
 	return nil
 }
+
+// acceptVoiceQuery 参数紧跟 "换" 时，只有能确定是在找音色才作为指令
+// 单个字的标签和短的近似名称容易和普通弹幕混淆，需要和指令名用空格分开
+func acceptVoiceQuery(args Args) bool {
+	res := config.SearchVoice(args.Raw)
+	n := utf8.RuneCountInString(args.Raw)
+	switch res.Match {
+	case config.VoiceMatchExact, config.VoiceMatchGender:
+		return true
+	case config.VoiceMatchTag:
+		return n >= 2
+	case config.VoiceMatchInitials, config.VoiceMatchFuzzy:
+		return n >= 3
+	default:
+		return false
+	}
+}
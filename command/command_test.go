@@ -0,0 +1,33 @@
+package command
+
+import "testing"
+
+// TestAcceptVoiceQuery 以 "换" 开头的弹幕，只有确实在找音色时才作为换音色指令
+func TestAcceptVoiceQuery(t *testing.T) {
+	tests := []struct {
+		danmaku string
+		want    bool
+	}{
+		{"换湾湾小何", true},
+		{"换wwxh", true},
+		{"换湾湾小河", true},
+		{"换女声", true},
+		{"换萌女声", true},
+		{"换 萌", true},
+		{"换女朋友", false},
+		{"换个游戏吧", false},
+		{"换一下", false},
+		{"换了", false},
+		{"换萌", false},
+		{"换衣服了吗", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.danmaku, func(t *testing.T) {
+			cmd, _, ok := Default.Match(tt.danmaku, PermEveryone)
+			got := ok && cmd.Name == "换"
+			if got != tt.want {
+				t.Errorf("%q 作为换音色指令 = %v, want %v", tt.danmaku, got, tt.want)
+			}
+		})
+	}
+}
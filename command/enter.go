@@ -1,21 +1,12 @@
 package command
 
 import (
-	"strings"
-
 	"github.com/CoffeeSwt/bilibili-tts-chat/response"
+	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
 )
 
-func CheckIfCommandAndUseHandler(msg *response.DanmakuMessage) (func(msg *response.DanmakuMessage) error, bool) {
-	text := msg.Data.Msg
-	if h, ok := Exact[text]; ok {
-		return func(m *response.DanmakuMessage) error { return h(m, "") }, true
-	}
-	for prefix, h := range Prefix {
-		if strings.HasPrefix(text, prefix) {
-			arg := strings.TrimSpace(strings.TrimPrefix(text, prefix))
-			return func(m *response.DanmakuMessage) error { return h(m, arg) }, true
-		}
-	}
-	return nil, false
+// CheckIfCommandAndUseHandler 检查弹幕是否为内置指令，是指令时返回处理函数
+// anchorOpenID 为主播的open_id，用于识别主播权限，为空时不识别
+func CheckIfCommandAndUseHandler(msg *response.DanmakuMessage, anchorOpenID string) (func(tm *task_manager.TaskManager, msg *response.DanmakuMessage) error, bool) {
+	return Default.Check(msg, anchorOpenID)
 }
@@ -0,0 +1,58 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/config"
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/response"
+	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
+	"github.com/CoffeeSwt/bilibili-tts-chat/user"
+)
+
+// SupporterOf 观众的身份，用于判断能否使用专属音色
+func SupporterOf(openID, uname string, guardLevel, fansMedalLevel int) config.Supporter {
+	return config.Supporter{
+		GuardLevel:     guardLevel,
+		FansMedalLevel: fansMedalLevel,
+		GiftValue:      user.GetGiftValue(openID, uname),
+	}
+}
+
+// supporterOf 弹幕发送者的身份
+func supporterOf(msg *response.DanmakuMessage) config.Supporter {
+	return SupporterOf(msg.Data.OpenID, msg.Data.UName, msg.Data.GuardLevel, msg.Data.FansMedalLevel)
+}
+
+// checkVoiceAvailable 检查发送者能否使用音色，不能使用时说明使用条件
+func checkVoiceAvailable(tm *task_manager.TaskManager, msg *response.DanmakuMessage, v *config.Voice) bool {
+	if v.AvailableTo(supporterOf(msg)) {
+		return true
+	}
+	logger.Info(fmt.Sprintf("[DanmakuHandler] 用户 %s 不满足专属音色 %s 的使用条件", msg.Data.UName, v.Name))
+	say(tm, msg, fmt.Sprintf("%s，%s是专属音色，%s就可以使用啦，可以先发送试听%s听听看", msg.Data.UName, v.Name, v.Requires, v.Name))
+	return false
+}
+
+// EnforceVoiceEligibility 观众不再满足专属音色的使用条件时（如大航海到期），把音色换回免费音色
+// 弹幕、礼物、付费留言等以观众音色播报的消息都要在播报前检查
+func EnforceVoiceEligibility(tm *task_manager.TaskManager, uname string, s config.Supporter) {
+	current := user.GetUserVoice(uname)
+	if current == nil || current.AvailableTo(s) {
+		return
+	}
+
+	v := config.GetRandomVoice()
+	if v == nil || v.IsPremium() {
+		return
+	}
+	if err := user.SetUserVoice(uname, v.VoiceType); err != nil {
+		logger.Error(fmt.Sprintf("[VoiceEligibility] 用户 %s 换回免费音色失败: %v", uname, err))
+		return
+	}
+	logger.Info(fmt.Sprintf("[VoiceEligibility] 用户 %s 不再满足专属音色 %s 的使用条件，已换回 %s", uname, current.Name, v.Name))
+	text := fmt.Sprintf("%s，专属音色%s需要%s，你的播报音色已换回%s", uname, current.Name, current.Requires, v.Name)
+	if err := tm.AddText(text, task_manager.TextTypeCommand, user.GetUserVoice(uname)); err != nil {
+		logger.Error(fmt.Sprintf("[VoiceEligibility] 添加提示到任务管理器失败: %v", err))
+	}
+}
@@ -0,0 +1,359 @@
+package command
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/response"
+	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
+)
+
+// Permission 指令的权限等级，高等级的用户可以使用低等级的指令
+type Permission int
+
+const (
+	PermEveryone Permission = iota // 所有观众
+	PermMedal                      // 佩戴本房间粉丝勋章
+	PermGuard                      // 大航海
+	PermAdmin                      // 房管
+	PermAnchor                     // 主播
+)
+
+// String 权限等级名称
+func (p Permission) String() string {
+	switch p {
+	case PermMedal:
+		return "粉丝勋章"
+	case PermGuard:
+		return "大航海"
+	case PermAdmin:
+		return "房管"
+	case PermAnchor:
+		return "主播"
+	default:
+		return "所有人"
+	}
+}
+
+// PermissionOf 弹幕发送者的权限等级，anchorOpenID 为主播的open_id，为空时不识别主播
+func PermissionOf(msg *response.DanmakuMessage, anchorOpenID string) Permission {
+	switch {
+	case anchorOpenID != "" && msg.Data.OpenID == anchorOpenID:
+		return PermAnchor
+	case msg.Data.IsAdmin == 1:
+		return PermAdmin
+	case msg.Data.GuardLevel > 0:
+		return PermGuard
+	case msg.Data.FansMedalWearingStatus && msg.Data.FansMedalLevel > 0:
+		return PermMedal
+	default:
+		return PermEveryone
+	}
+}
+
+// Handler 指令处理函数
+type Handler func(tm *task_manager.TaskManager, msg *response.DanmakuMessage, args Args) error
+
+// Args 指令参数
+type Args struct {
+	Raw    string     // 指令名后面的全部内容，去掉首尾空白
+	Fields []string   // 按空白分隔的参数
+	Caller Permission // 发送者的权限等级
+}
+
+// parseArgs 解析指令名后面的内容
+func parseArgs(raw string) Args {
+	raw = strings.TrimSpace(raw)
+	return Args{Raw: raw, Fields: strings.Fields(raw)}
+}
+
+// Get 第i个参数，不存在时返回空字符串
+func (a Args) Get(i int) string {
+	if i < 0 || i >= len(a.Fields) {
+		return ""
+	}
+	return a.Fields[i]
+}
+
+// Int 第i个参数转换为整数，不存在时返回def
+func (a Args) Int(i int, def int) (int, error) {
+	s := a.Get(i)
+	if s == "" {
+		return def, nil
+	}
+	return strconv.Atoi(s)
+}
+
+// Command 一条指令
+type Command struct {
+	Name        string     // 指令名
+	Aliases     []string   // 别名
+	Params      string     // 参数说明，如 "音色名"，为空时指令不带参数，只有完整匹配时才作为指令
+	Description string     // 指令说明，用于帮助
+	Permission  Permission // 最低权限等级，权限不够的用户发送时按普通弹幕处理
+
+	// Accept 参数紧跟指令名（中间没有空白）时检查参数，不通过时不作为指令，避免普通弹幕被误认为指令
+	// 为空时不检查
+	Accept func(args Args) bool
+
+	UserCooldown   time.Duration // 同一用户两次使用的最短间隔
+	GlobalCooldown time.Duration // 所有用户两次使用的最短间隔
+
+	// UserCooldownFunc 从配置读取同一用户的冷却时间，设置后代替 UserCooldown，修改配置后立即生效
+	UserCooldownFunc func() time.Duration
+
+	Handler Handler
+}
+
+// names 指令名和别名
+func (c *Command) names() []string {
+	return append([]string{c.Name}, c.Aliases...)
+}
+
+// usage 指令用法，如 "音量（1到100）"
+func (c *Command) usage() string {
+	if c.Params == "" {
+		return c.Name
+	}
+	return fmt.Sprintf("%s（%s）", c.Name, c.Params)
+}
+
+// prunePeriod 清理过期冷却记录的间隔
+const prunePeriod = time.Minute
+
+// useRecord 一次指令使用记录
+type useRecord struct {
+	cmd *Command
+	at  time.Time
+}
+
+// Router 指令路由，按最长的指令名匹配弹幕，检查权限和冷却时间
+type Router struct {
+	mu        sync.Mutex
+	commands  []*Command
+	byName    map[string]*Command  // 指令名和别名 -> 指令
+	lastUsed  map[string]useRecord // 冷却键 -> 上次使用
+	lastPrune time.Time            // 上次清理冷却记录的时间
+}
+
+// NewRouter 创建指令路由，自动注册帮助指令
+func NewRouter() *Router {
+	r := &Router{
+		byName:   make(map[string]*Command),
+		lastUsed: make(map[string]useRecord),
+	}
+	r.MustRegister(&Command{
+		Name:           "帮助",
+		Aliases:        []string{"指令", "help"},
+		Params:         "指令名",
+		Description:    "查看可以使用的指令",
+		Accept:         func(args Args) bool { return r.lookup(args.Raw) != nil },
+		UserCooldown:   30 * time.Second,
+		GlobalCooldown: 5 * time.Second,
+		Handler:        r.handleHelp,
+	})
+	return r
+}
+
+// Register 注册指令，指令名或别名已被使用时返回错误
+func (r *Router) Register(cmd *Command) error {
+	if cmd.Name == "" || cmd.Handler == nil {
+		return fmt.Errorf("指令名和处理函数不能为空")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, name := range cmd.names() {
+		if _, exists := r.byName[name]; exists {
+			return fmt.Errorf("指令名 %s 已被使用", name)
+		}
+	}
+	for _, name := range cmd.names() {
+		r.byName[name] = cmd
+	}
+	r.commands = append(r.commands, cmd)
+	return nil
+}
+
+// MustRegister 注册指令，失败时panic，用于注册内置指令
+func (r *Router) MustRegister(cmd *Command) {
+	if err := r.Register(cmd); err != nil {
+		panic(err)
+	}
+}
+
+// lookup 按指令名或别名查找指令
+func (r *Router) lookup(name string) *Command {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.byName[name]
+}
+
+// Commands 返回权限等级为 perm 的用户可以使用的指令
+func (r *Router) Commands(perm Permission) []*Command {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var cmds []*Command
+	for _, cmd := range r.commands {
+		if cmd.Permission <= perm {
+			cmds = append(cmds, cmd)
+		}
+	}
+	return cmds
+}
+
+// Match 查找弹幕对应的指令，用户没有权限的指令不会被匹配
+// 完整匹配优先，其次按最长的指令名匹配带参数的指令：指令名后面有空白时直接匹配，紧跟参数时还需要通过 Accept 检查
+func (r *Router) Match(text string, perm Permission) (*Command, Args, bool) {
+	text = strings.TrimSpace(text)
+
+	r.mu.Lock()
+	if cmd, ok := r.byName[text]; ok && cmd.Permission <= perm {
+		r.mu.Unlock()
+		return cmd, Args{Caller: perm}, true
+	}
+
+	type candidate struct {
+		name string
+		cmd  *Command
+	}
+	var candidates []candidate
+	for name, cmd := range r.byName {
+		if cmd.Params != "" && cmd.Permission <= perm && len(text) > len(name) && strings.HasPrefix(text, name) {
+			candidates = append(candidates, candidate{name, cmd})
+		}
+	}
+	r.mu.Unlock()
+
+	// 最长的指令名优先，长度相同时按名称排序，保证结果稳定
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		if len(a.name) != len(b.name) {
+			return len(b.name) - len(a.name)
+		}
+		return strings.Compare(a.name, b.name)
+	})
+
+	for _, c := range candidates {
+		rest := text[len(c.name):]
+		args := parseArgs(rest)
+		args.Caller = perm
+		delimited := unicode.IsSpace([]rune(rest)[0])
+		if delimited || c.cmd.Accept == nil || c.cmd.Accept(args) {
+			return c.cmd, args, true
+		}
+	}
+	return nil, Args{}, false
+}
+
+// userCooldown 同一用户两次使用的最短间隔
+func (c *Command) userCooldown() time.Duration {
+	if c.UserCooldownFunc != nil {
+		return c.UserCooldownFunc()
+	}
+	return c.UserCooldown
+}
+
+// cooldown 检查冷却时间，没有冷却时记录本次使用，返回还需要等待的时间
+func (r *Router) cooldown(cmd *Command, msg *response.DanmakuMessage) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.pruneInternal(now)
+	userCooldown := cmd.userCooldown()
+	globalKey := fmt.Sprintf("%d:%s", msg.Data.RoomID, cmd.Name)
+	userKey := fmt.Sprintf("%s:%s", globalKey, msg.Data.OpenID)
+	if msg.Data.OpenID == "" {
+		userKey = fmt.Sprintf("%s:%s", globalKey, msg.Data.UName)
+	}
+
+	var wait time.Duration
+	if last, ok := r.lastUsed[globalKey]; ok {
+		wait = max(wait, cmd.GlobalCooldown-now.Sub(last.at))
+	}
+	if last, ok := r.lastUsed[userKey]; ok {
+		wait = max(wait, userCooldown-now.Sub(last.at))
+	}
+	if wait > 0 {
+		return wait
+	}
+
+	if cmd.GlobalCooldown > 0 {
+		r.lastUsed[globalKey] = useRecord{cmd, now}
+	}
+	if userCooldown > 0 {
+		r.lastUsed[userKey] = useRecord{cmd, now}
+	}
+	return 0
+}
+
+// pruneInternal 每隔 prunePeriod 删除已经过了冷却时间的记录，调用前需要加锁
+func (r *Router) pruneInternal(now time.Time) {
+	if now.Sub(r.lastPrune) < prunePeriod {
+		return
+	}
+	r.lastPrune = now
+	for key, u := range r.lastUsed {
+		if now.Sub(u.at) >= max(u.cmd.GlobalCooldown, u.cmd.userCooldown()) {
+			delete(r.lastUsed, key)
+		}
+	}
+}
+
+// Check 检查弹幕是否为指令，是指令时返回处理函数
+// 冷却中的指令也算作指令，处理函数只记录日志，不会作为普通弹幕播报
+func (r *Router) Check(msg *response.DanmakuMessage, anchorOpenID string) (func(tm *task_manager.TaskManager, msg *response.DanmakuMessage) error, bool) {
+	perm := PermissionOf(msg, anchorOpenID)
+	cmd, args, ok := r.Match(msg.Data.Msg, perm)
+	if !ok {
+		return nil, false
+	}
+
+	if wait := r.cooldown(cmd, msg); wait > 0 {
+		return func(tm *task_manager.TaskManager, m *response.DanmakuMessage) error {
+			logger.Info(fmt.Sprintf("[指令] 用户 %s 的指令 %s 冷却中，还需等待 %v", m.Data.UName, cmd.Name, wait.Round(time.Second)))
+			return nil
+		}, true
+	}
+
+	if cmd.Permission > PermEveryone {
+		logger.Info(fmt.Sprintf("[指令] %s %s 使用指令: %s", perm, msg.Data.UName, msg.Data.Msg))
+	}
+	return func(tm *task_manager.TaskManager, m *response.DanmakuMessage) error {
+		return cmd.Handler(tm, m, args)
+	}, true
+}
+
+// handleHelp 帮助指令，列出发送者可以使用的指令，带指令名时说明该指令的用法
+func (r *Router) handleHelp(tm *task_manager.TaskManager, msg *response.DanmakuMessage, args Args) error {
+	var text string
+	if name := args.Get(0); name != "" {
+		cmd := r.lookup(name)
+		if cmd == nil {
+			text = fmt.Sprintf("%s，没有找到指令 %s", msg.Data.UName, name)
+		} else {
+			text = fmt.Sprintf("%s：%s", cmd.usage(), cmd.Description)
+			if cmd.Permission > PermEveryone {
+				text += fmt.Sprintf("，需要%s权限", cmd.Permission)
+			}
+		}
+	} else {
+		var usages []string
+		for _, cmd := range r.Commands(args.Caller) {
+			usages = append(usages, cmd.usage())
+		}
+		text = fmt.Sprintf("%s可以使用的指令有：%s", msg.Data.UName, strings.Join(usages, "、"))
+	}
+
+	logger.Info(fmt.Sprintf("[指令] 用户 %s 查看帮助: %s", msg.Data.UName, text))
+	say(tm, msg, text)
+	return nil
+}
@@ -0,0 +1,115 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/response"
+	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
+)
+
+func nopHandler(*task_manager.TaskManager, *response.DanmakuMessage, Args) error { return nil }
+
+// newTestRouter 创建带几条测试指令的路由
+func newTestRouter() *Router {
+	r := NewRouter()
+	r.MustRegister(&Command{Name: "跳过", Handler: nopHandler})
+	r.MustRegister(&Command{Name: "试听", Params: "音色名", Handler: nopHandler})
+	r.MustRegister(&Command{Name: "试听音色", Params: "音色名", Handler: nopHandler})
+	r.MustRegister(&Command{Name: "音量", Params: "1到100", Accept: isNumber, Permission: PermAdmin, Handler: nopHandler})
+	r.MustRegister(&Command{Name: "禁言", Params: "用户名", Accept: never, Permission: PermAdmin, Handler: nopHandler})
+	r.MustRegister(&Command{Name: "点歌", Aliases: []string{"点"}, Params: "歌名", Permission: PermMedal, Handler: nopHandler})
+	return r
+}
+
+func TestMatch(t *testing.T) {
+	r := newTestRouter()
+
+	tests := []struct {
+		name     string
+		text     string
+		perm     Permission
+		wantCmd  string // 为空时不应匹配
+		wantArgs string
+	}{
+		{name: "完整匹配", text: "跳过", perm: PermEveryone, wantCmd: "跳过"},
+		{name: "完整匹配去掉首尾空白", text: " 跳过 ", perm: PermEveryone, wantCmd: "跳过"},
+		{name: "不带参数的指令不按前缀匹配", text: "跳过这首", perm: PermEveryone},
+		{name: "最长的指令名优先", text: "试听音色湾湾小何", perm: PermEveryone, wantCmd: "试听音色", wantArgs: "湾湾小何"},
+		{name: "较短的指令名", text: "试听湾湾小何", perm: PermEveryone, wantCmd: "试听", wantArgs: "湾湾小何"},
+		{name: "空格分隔", text: "试听 湾湾小何", perm: PermEveryone, wantCmd: "试听", wantArgs: "湾湾小何"},
+		{name: "全角空格分隔", text: "禁言　小明", perm: PermAdmin, wantCmd: "禁言", wantArgs: "小明"},
+		{name: "Accept通过", text: "音量50", perm: PermAdmin, wantCmd: "音量", wantArgs: "50"},
+		{name: "Accept不通过", text: "音量太大了", perm: PermAdmin},
+		{name: "空格分隔时不检查Accept", text: "音量 太大了", perm: PermAdmin, wantCmd: "音量", wantArgs: "太大了"},
+		{name: "必须空格分隔", text: "禁言小明", perm: PermAdmin},
+		{name: "权限不够", text: "音量 50", perm: PermGuard},
+		{name: "权限不够的完整匹配", text: "禁言", perm: PermEveryone},
+		{name: "高权限可以使用低权限指令", text: "点歌 晴天", perm: PermAnchor, wantCmd: "点歌", wantArgs: "晴天"},
+		{name: "别名", text: "点 晴天", perm: PermMedal, wantCmd: "点歌", wantArgs: "晴天"},
+		{name: "别名前缀不影响最长匹配", text: "点歌晴天", perm: PermMedal, wantCmd: "点歌", wantArgs: "晴天"},
+		{name: "普通弹幕", text: "主播好", perm: PermEveryone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, args, ok := r.Match(tt.text, tt.perm)
+			if tt.wantCmd == "" {
+				if ok {
+					t.Fatalf("%q 不应匹配指令, got %s", tt.text, cmd.Name)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("%q 应匹配指令 %s", tt.text, tt.wantCmd)
+			}
+			if cmd.Name != tt.wantCmd {
+				t.Errorf("%q 匹配到 %s, want %s", tt.text, cmd.Name, tt.wantCmd)
+			}
+			if args.Raw != tt.wantArgs {
+				t.Errorf("%q 参数 = %q, want %q", tt.text, args.Raw, tt.wantArgs)
+			}
+			if args.Caller != tt.perm {
+				t.Errorf("Caller = %v, want %v", args.Caller, tt.perm)
+			}
+		})
+	}
+}
+
+func TestCooldownPrune(t *testing.T) {
+	r := NewRouter()
+	cmd := &Command{Name: "测试", UserCooldown: time.Minute, GlobalCooldown: time.Second, Handler: nopHandler}
+	r.MustRegister(cmd)
+
+	msg := &response.DanmakuMessage{}
+	msg.Data.RoomID = 1
+	for _, openID := range []string{"a", "b", "c"} {
+		msg.Data.OpenID = openID
+		r.lastPrune = time.Time{}
+		r.cooldown(cmd, msg)
+		r.lastUsed = backdate(r.lastUsed, 2*time.Second)
+	}
+	msg.Data.OpenID = "a"
+	if wait := r.cooldown(cmd, msg); wait <= 0 {
+		t.Fatal("同一用户冷却中应返回等待时间")
+	}
+
+	// 所有记录都超过了冷却时间，下一次使用时清理
+	r.lastUsed = backdate(r.lastUsed, 2*time.Minute)
+	r.lastPrune = time.Time{}
+	msg.Data.OpenID = "d"
+	if wait := r.cooldown(cmd, msg); wait != 0 {
+		t.Fatalf("wait = %v, want 0", wait)
+	}
+	if len(r.lastUsed) != 2 {
+		t.Errorf("清理后剩余 %d 条记录, want 2（本次使用的全局和用户记录）", len(r.lastUsed))
+	}
+}
+
+// backdate 把所有使用记录提前 d
+func backdate(m map[string]useRecord, d time.Duration) map[string]useRecord {
+	for key, u := range m {
+		u.at = u.at.Add(-d)
+		m[key] = u
+	}
+	return m
+}
@@ -0,0 +1,110 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/config"
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/response"
+	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
+	"github.com/CoffeeSwt/bilibili-tts-chat/user"
+)
+
+// normalOffsetWords 恢复正常语速或音量的参数
+var normalOffsetWords = map[string]bool{"正常": true, "默认": true, "恢复": true}
+
+// parseOffset 解析语速或音量偏移参数，如 "+20"、"-10"、"正常"
+func parseOffset(s string) (int, bool) {
+	if normalOffsetWords[s] {
+		return 0, true
+	}
+	rate, err := strconv.Atoi(s)
+	return rate, err == nil
+}
+
+// acceptSpeechRate 参数紧跟 "语速" 时，只有是语速才作为指令
+func acceptSpeechRate(args Args) bool {
+	_, ok := parseOffset(args.Raw)
+	return ok
+}
+
+// formatSpeechRate 语速偏移的播报文字
+func formatSpeechRate(rate int) string {
+	if rate == 0 {
+		return "正常语速"
+	}
+	return fmt.Sprintf("%+d", rate)
+}
+
+// acceptUserVolume 参数紧跟 "我的音量" 时，只有是音量偏移才作为指令
+func acceptUserVolume(args Args) bool {
+	_, ok := parseOffset(args.Raw)
+	return ok
+}
+
+// formatUserVolume 音量偏移的播报文字
+func formatUserVolume(volume int) string {
+	if volume == 0 {
+		return "正常音量"
+	}
+	return fmt.Sprintf("%+d", volume)
+}
+
+// handleSpeechRate 设置自己弹幕的语速，不带参数时查询当前语速
+func handleSpeechRate(tm *task_manager.TaskManager, msg *response.DanmakuMessage, args Args) error {
+	if args.Raw == "" {
+		rate := user.GetUserSpeechRate(msg.Data.UName)
+		say(tm, msg, fmt.Sprintf("%s当前的语速是%s", msg.Data.UName, formatSpeechRate(rate)))
+		return nil
+	}
+
+	rate, ok := parseOffset(args.Raw)
+	if !ok || rate < config.MinSpeechRate || rate > config.MaxSpeechRate {
+		say(tm, msg, fmt.Sprintf("%s，语速可以设置为%d到%d，或者发送语速 正常", msg.Data.UName, config.MinSpeechRate, config.MaxSpeechRate))
+		return nil
+	}
+
+	if err := user.SetUserSpeechRate(msg.Data.UName, rate); err != nil {
+		logger.Error(fmt.Sprintf("[DanmakuHandler] 用户 %s 设置语速失败: %v", msg.Data.UName, err))
+		return nil
+	}
+	logger.Info(fmt.Sprintf("[DanmakuHandler] 用户 %s 设置语速为: %s", msg.Data.UName, formatSpeechRate(rate)))
+
+	// 回复使用新的语速，观众可以直接听到效果
+	if rate == 0 {
+		say(tm, msg, fmt.Sprintf("%s已恢复正常语速", msg.Data.UName))
+	} else {
+		say(tm, msg, fmt.Sprintf("%s的语速已调整为%s", msg.Data.UName, formatSpeechRate(rate)))
+	}
+	return nil
+}
+
+// handleUserVolume 设置自己弹幕的音量，不带参数时查询当前音量
+func handleUserVolume(tm *task_manager.TaskManager, msg *response.DanmakuMessage, args Args) error {
+	if args.Raw == "" {
+		volume := user.GetUserVolume(msg.Data.UName)
+		say(tm, msg, fmt.Sprintf("%s当前的音量是%s", msg.Data.UName, formatUserVolume(volume)))
+		return nil
+	}
+
+	volume, ok := parseOffset(args.Raw)
+	if !ok || volume < config.MinLoudnessRate || volume > config.MaxLoudnessRate {
+		say(tm, msg, fmt.Sprintf("%s，音量可以设置为%d到%d，或者发送我的音量 正常", msg.Data.UName, config.MinLoudnessRate, config.MaxLoudnessRate))
+		return nil
+	}
+
+	if err := user.SetUserVolume(msg.Data.UName, volume); err != nil {
+		logger.Error(fmt.Sprintf("[DanmakuHandler] 用户 %s 设置音量失败: %v", msg.Data.UName, err))
+		return nil
+	}
+	logger.Info(fmt.Sprintf("[DanmakuHandler] 用户 %s 设置音量为: %s", msg.Data.UName, formatUserVolume(volume)))
+
+	// 回复使用新的音量，观众可以直接听到效果
+	if volume == 0 {
+		say(tm, msg, fmt.Sprintf("%s已恢复正常音量", msg.Data.UName))
+	} else {
+		say(tm, msg, fmt.Sprintf("%s的音量已调整为%s", msg.Data.UName, formatUserVolume(volume)))
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/config"
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/reply_template"
+	"github.com/CoffeeSwt/bilibili-tts-chat/response"
+	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
+)
+
+// voicesPerPage 音色列表每页播报的音色数
+const voicesPerPage = 10
+
+// VoicePreviewEvent 试听音色回复模板的数据
+type VoicePreviewEvent struct {
+	UName     string
+	VoiceName string
+}
+
+// handlePreviewVoice 用指定的音色播报示例句子，不修改用户的音色
+func handlePreviewVoice(tm *task_manager.TaskManager, msg *response.DanmakuMessage, args Args) error {
+	if args.Raw == "" {
+		say(tm, msg, fmt.Sprintf("%s，请在试听后面加上音色名", msg.Data.UName))
+		return nil
+	}
+
+	res := config.SearchVoice(args.Raw)
+	switch {
+	case res.Voice != nil:
+		v := res.Voice
+		text, err := reply_template.Render(reply_template.EventVoicePreview, VoicePreviewEvent{
+			UName:     msg.Data.UName,
+			VoiceName: v.Name,
+		})
+		if err != nil {
+			return fmt.Errorf("生成试听句子失败: %v", err)
+		}
+		logger.Info(fmt.Sprintf("[DanmakuHandler] 用户 %s 按%s \"%s\" 试听音色: %s", msg.Data.UName, res.Match, args.Raw, v.Name))
+		if err := tm.AddText(text, task_manager.TextTypeCommand, v); err != nil {
+			logger.Error(fmt.Sprintf("[DanmakuHandler] 添加试听到任务管理器失败: %v", err))
+		}
+	case len(res.Candidates) > 0:
+		say(tm, msg, fmt.Sprintf("%s，你是不是想试听%s", msg.Data.UName, voiceNames(res.Candidates)))
+	default:
+		say(tm, msg, fmt.Sprintf("%s，没有找到音色%s", msg.Data.UName, args.Raw))
+	}
+	return nil
+}
+
+// handleListVoices 播报一页音色名称，参数为页码或标签，也可以是 "标签 页码"
+func handleListVoices(tm *task_manager.TaskManager, msg *response.DanmakuMessage, args Args) error {
+	tag, page := args.Raw, 1
+	if n := len(args.Fields); n > 0 {
+		if p, err := strconv.Atoi(args.Fields[n-1]); err == nil {
+			tag, page = strings.Join(args.Fields[:n-1], " "), p
+		}
+	}
+
+	voices := config.VoicesWithTag(tag)
+	if len(voices) == 0 {
+		say(tm, msg, fmt.Sprintf("%s，没有找到%s相关的音色", msg.Data.UName, tag))
+		return nil
+	}
+	pages := (len(voices) + voicesPerPage - 1) / voicesPerPage
+	page = min(max(page, 1), pages)
+	start := (page - 1) * voicesPerPage
+	end := min(start+voicesPerPage, len(voices))
+
+	var text strings.Builder
+	if tag != "" {
+		text.WriteString(tag + "相关的")
+	}
+	fmt.Fprintf(&text, "音色共%d个，第%d页共%d页：%s", len(voices), page, pages, catalogueNames(voices[start:end]))
+	if page < pages {
+		next := strconv.Itoa(page + 1)
+		if tag != "" {
+			next = tag + " " + next
+		}
+		fmt.Fprintf(&text, "，发送音色列表 %s 查看下一页", next)
+	}
+	logger.Info(fmt.Sprintf("[DanmakuHandler] 用户 %s 查看音色列表: %s", msg.Data.UName, text.String()))
+	say(tm, msg, text.String())
+	return nil
+}
+
+// catalogueNames 音色列表中的音色名称，专属音色加上标记
+func catalogueNames(voices []*config.Voice) string {
+	names := make([]string, 0, len(voices))
+	for _, v := range voices {
+		if v.IsPremium() {
+			names = append(names, v.Name+"（专属）")
+		} else {
+			names = append(names, v.Name)
+		}
+	}
+	return strings.Join(names, "、")
+}
+
+// voiceNames 音色名称，用顿号分隔
+func voiceNames(voices []*config.Voice) string {
+	names := make([]string, 0, len(voices))
+	for _, v := range voices {
+		names = append(names, v.Name)
+	}
+	return strings.Join(names, "、")
+}
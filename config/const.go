@@ -1,6 +1,35 @@
 package config
 
+import "sync"
+
 const (
 	OpenPlatformHttpHost = "https://live-open.biliapi.com"
 	TTSHttpV3Host        = "https://openspeech.bytedance.com/api/v3/tts/unidirectional"
 )
+
+var (
+	openPlatformHttpHostOverride string
+	hostMutex                    sync.RWMutex
+)
+
+// SetOpenPlatformHttpHost 覆盖B站开放平台地址，传空字符串恢复默认，用于本地模拟服务
+func SetOpenPlatformHttpHost(host string) {
+	hostMutex.Lock()
+	defer hostMutex.Unlock()
+	openPlatformHttpHostOverride = host
+}
+
+// GetOpenPlatformHttpHost 获取B站开放平台地址
+// 优先级：SetOpenPlatformHttpHost > .env 中的 bili_open_platform_host > 默认地址
+func GetOpenPlatformHttpHost() string {
+	hostMutex.RLock()
+	override := openPlatformHttpHostOverride
+	hostMutex.RUnlock()
+	if override != "" {
+		return override
+	}
+	if host := GetEnvConfig().BiliOpenPlatformHost; host != "" {
+		return host
+	}
+	return OpenPlatformHttpHost
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"strings"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// gb2312Initials GB2312一级汉字按拼音排序，每个声母的第一个汉字的编码
+// 二级汉字按部首排序，无法通过编码得到拼音
+var gb2312Initials = []struct {
+	start   uint16
+	initial byte
+}{
+	{0xB0A1, 'a'}, {0xB0C5, 'b'}, {0xB2C1, 'c'}, {0xB4EE, 'd'}, {0xB6EA, 'e'},
+	{0xB7A2, 'f'}, {0xB8C1, 'g'}, {0xB9FE, 'h'}, {0xBBF7, 'j'}, {0xBFA6, 'k'},
+	{0xC0AC, 'l'}, {0xC2E8, 'm'}, {0xC4C3, 'n'}, {0xC5B6, 'o'}, {0xC5BE, 'p'},
+	{0xC6DA, 'q'}, {0xC8BB, 'r'}, {0xC8F6, 's'}, {0xCBFA, 't'}, {0xCDDA, 'w'},
+	{0xCEF4, 'x'}, {0xD1B9, 'y'}, {0xD4D1, 'z'},
+}
+
+// gb2312Level1End GB2312一级汉字的最后一个编码
+const gb2312Level1End = 0xD7F9
+
+// pinyinInitials 汉字的拼音首字母，如 "湾湾小何" -> "wwxh"
+// 字母和数字保留为小写，无法识别的字符跳过，多音字取编码表中的读音
+func pinyinInitials(s string) string {
+	enc := simplifiedchinese.GBK.NewEncoder()
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r < 0x80:
+			if ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') {
+				b.WriteRune(r)
+			} else if 'A' <= r && r <= 'Z' {
+				b.WriteRune(r - 'A' + 'a')
+			}
+		default:
+			gbk, err := enc.Bytes([]byte(string(r)))
+			if err != nil || len(gbk) != 2 {
+				continue
+			}
+			code := uint16(gbk[0])<<8 | uint16(gbk[1])
+			if code < gb2312Initials[0].start || code > gb2312Level1End {
+				continue
+			}
+			initial := gb2312Initials[0].initial
+			for _, g := range gb2312Initials {
+				if code < g.start {
+					break
+				}
+				initial = g.initial
+			}
+			b.WriteByte(initial)
+		}
+	}
+	return b.String()
+}
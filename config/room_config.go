@@ -0,0 +1,63 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// 直播间音频输出方式
+const (
+	RoomOutputDevice = "device" // 在默认声卡上播放
+	RoomOutputFile   = "file"   // 写入mp3文件
+)
+
+// RoomConfig 多直播间模式下单个直播间的配置，保存在 rooms.json 中
+// 未填写的人设字段使用 user.json 中的配置
+type RoomConfig struct {
+	Name                string `json:"name"`                  // 直播间名称 // 用于区分直播间，必须唯一
+	RoomIDCode          string `json:"room_id_code"`          // 直播间身份码
+	RoomDescription     string `json:"room_description"`      // 直播间描述
+	AssistantName       string `json:"assistant_name"`        // 助手名称
+	AssistantMemorySize int    `json:"assistant_memory_size"` // 助手的记忆大小
+	Output              string `json:"output"`                // 音频输出 // device 为默认声卡，file 为写入 output_dir 目录
+	OutputDir           string `json:"output_dir"`            // 音频文件输出目录 // 为空时使用 audio/<name>
+	Volume              int    `json:"volume"`                // 播报音量 // 1到100，为0时使用 user.json 中的音量，只对声卡输出有效
+	AutoStart           bool   `json:"auto_start"`            // 是否随程序启动
+	WebhookAddr         string `json:"webhook_addr"`          // 本地Webhook监听地址 // 为空时不启动，多个直播间不能使用相同地址
+	WebhookToken        string `json:"webhook_token"`         // 本地Webhook访问令牌 // 为空时不校验，只能监听本机地址
+	TailFile            string `json:"tail_file"`             // 跟踪的事件文件
+}
+
+// LoadRoomConfigs 从 rooms.json 加载所有直播间配置
+func LoadRoomConfigs() ([]RoomConfig, error) {
+	wd, _ := os.Getwd()
+	configPath, ok := findFileUpwards(wd, "rooms.json")
+	if !ok {
+		return nil, fmt.Errorf("未找到 rooms.json")
+	}
+
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取直播间配置文件失败: %v", err)
+	}
+	var rooms []RoomConfig
+	if err := json.Unmarshal(content, &rooms); err != nil {
+		return nil, fmt.Errorf("解析直播间配置文件失败: %v", err)
+	}
+
+	names := make(map[string]bool, len(rooms))
+	for _, room := range rooms {
+		if room.Name == "" {
+			return nil, fmt.Errorf("直播间名称不能为空")
+		}
+		if names[room.Name] {
+			return nil, fmt.Errorf("直播间名称重复: %s", room.Name)
+		}
+		names[room.Name] = true
+		if room.Volume < 0 || room.Volume > 100 {
+			return nil, fmt.Errorf("直播间 %s 的音量需要在1到100之间", room.Name)
+		}
+	}
+	return rooms, nil
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"sync"
+	"time"
 )
 
 type UserConfig struct {
@@ -19,8 +21,46 @@ type UserConfig struct {
 	AssistantMemorySize int    `json:"assistant_memory_size"` // 助手的记忆大小
 	UseLLMReplay        bool   `json:"use_llm_replay"`        // 是否使用LLM回复 // 用于指定是否使用LLM模型回复用户消息，为true时表示使用，为false时表示不使用
 	FirstStart          bool   `json:"first_start"`           // 是否第一次启动 // 用于指定是否第一次启动程序，为true时表示第一次启动，为false时表示不是第一次启动，第一次启动用于初始化配置
+
+	WsMaxReconnects         int `json:"ws_max_reconnects"`          // 长连最大重试次数 // 超过后会重新建立场次，默认10
+	WsReconnectBaseDelay    int `json:"ws_reconnect_base_delay"`    // 重连基础等待时间 // 单位为秒，按指数退避增长，默认1
+	WsReconnectMaxDelay     int `json:"ws_reconnect_max_delay"`     // 重连最大等待时间 // 单位为秒，默认30
+	AppHeartbeatMaxFailures int `json:"app_heartbeat_max_failures"` // 应用心跳最大连续失败次数 // 超过后会重新建立场次，默认3
+
+	RecordEvents bool `json:"record_events"` // 是否录制直播事件 // 为true时每次启动会把收到的原始消息写入 recordings 目录，可用于回放复现问题
+
+	DedupWindow   int `json:"dedup_window"`   // 消息去重时间窗口 // 单位为秒，窗口内msg_id相同的消息只处理一次，默认600
+	DedupCapacity int `json:"dedup_capacity"` // 消息去重最多记录的消息数 // 默认10000
+
+	WebhookAddr  string `json:"webhook_addr"`  // 本地Webhook监听地址 // 如 127.0.0.1:8090，为空时不启动，可用于注入手动弹幕和外部事件
+	WebhookToken string `json:"webhook_token"` // 本地Webhook访问令牌 // 为空时不校验，只能监听本机地址
+	TailFile     string `json:"tail_file"`     // 跟踪的事件文件 // 文件中新增的每一行作为一条消息处理，为空时不启用
+
+	GiftComboTimeout int            `json:"gift_combo_timeout"` // 礼物合并等待时间 // 单位为秒，消息没有带连击有效期时，同一用户的同一礼物在该时间内合并播报，默认5
+	BlindBoxPrices   map[string]int `json:"blind_box_prices"`   // 盲盒单价表 // 键为盲盒id（blind_gift_id），值为单价（1000 = 1元 = 10电池），消息中没有盲盒单价时使用
+
+	LikeWindow       int `json:"like_window"`         // 点赞合并窗口 // 单位为秒，收到第一个点赞后等待该时间，把窗口内所有用户的点赞合并为一句播报，默认10
+	LikeMinCount     int `json:"like_min_count"`      // 点赞播报门槛 // 窗口内点赞总数低于该值时不播报，默认10
+	LikeUserMinCount int `json:"like_user_min_count"` // 单个用户的点赞播报门槛 // 窗口内点赞数低于该值的用户不计入播报，默认3
+
+	WelcomeCooldown     int `json:"welcome_cooldown"`       // 进房欢迎冷却时间 // 单位为秒，同一用户在该时间内重复进入不再欢迎，默认600
+	WelcomeMaxPerMinute int `json:"welcome_max_per_minute"` // 每分钟最多欢迎次数 // 超过后不再欢迎，默认10
+	WelcomeBackDays     int `json:"welcome_back_days"`      // 老观众回归天数 // 距上次活跃超过该天数时播报好久不见，默认3
+
+	EmojiMode        string            `json:"emoji_mode"`         // 表情弹幕处理方式 // read 播报表情名，skip 不播报，merge 同一用户一段时间内的表情合并为一句播报，默认read
+	EmojiMergeWindow int               `json:"emoji_merge_window"` // 表情合并等待时间 // 单位为秒，emoji_mode 为 merge 时生效，默认5
+	EmojiNames       map[string]string `json:"emoji_names"`        // 表情名称字典 // 键为表情弹幕的文本或图片地址，值为播报的表情名
+
+	VoicePreviewCooldown int `json:"voice_preview_cooldown"` // 试听音色冷却时间 // 单位为秒，同一用户在该时间内只能试听一次，默认60
 }
 
+// 表情弹幕处理方式
+const (
+	EmojiModeRead  = "read"  // 播报表情名
+	EmojiModeSkip  = "skip"  // 不播报
+	EmojiModeMerge = "merge" // 同一用户的表情合并播报
+)
+
 // 全局配置实例
 var (
 	_userConfig *UserConfig
@@ -99,15 +139,219 @@ func GetAssistantMemorySize() int {
 	return GetUserConfig().AssistantMemorySize
 }
 
+// 语速范围，100代表2.0倍速，-50代表0.5倍速
+const (
+	MinSpeechRate = -50
+	MaxSpeechRate = 100
+)
+
 func GetSpeechRate() int {
-	rate := min(max(GetUserConfig().SpeechRate, -50), 100)
-	return rate
+	return ClampSpeechRate(GetUserConfig().SpeechRate)
+}
+
+// ClampSpeechRate 把语速限制在 [MinSpeechRate, MaxSpeechRate] 内
+func ClampSpeechRate(rate int) int {
+	return min(max(rate, MinSpeechRate), MaxSpeechRate)
+}
+
+// 观众音量偏移范围，对应TTS的 loudness_rate，100代表2.0倍音量，-50代表0.5倍音量
+const (
+	MinLoudnessRate = -50
+	MaxLoudnessRate = 100
+)
+
+// ClampLoudnessRate 把音量偏移限制在 [MinLoudnessRate, MaxLoudnessRate] 内
+func ClampLoudnessRate(rate int) int {
+	return min(max(rate, MinLoudnessRate), MaxLoudnessRate)
 }
 
 func GetUseLLMReplay() bool {
 	return GetUserConfig().UseLLMReplay
 }
 
+// SetVolume 修改音量并保存到 user.json，下一句播报开始生效
+func SetVolume(volume int) error {
+	cfg := *GetUserConfig()
+	cfg.Volume = min(max(volume, 1), 100)
+	return SaveUserConfig(cfg)
+}
+
+// SetUseLLMReplay 修改是否使用LLM回复并保存到 user.json
+func SetUseLLMReplay(use bool) error {
+	cfg := *GetUserConfig()
+	cfg.UseLLMReplay = use
+	return SaveUserConfig(cfg)
+}
+
+// GetWsMaxReconnects 获取长连最大重试次数
+func GetWsMaxReconnects() int {
+	if n := GetUserConfig().WsMaxReconnects; n > 0 {
+		return n
+	}
+	return 10
+}
+
+// GetWsReconnectBaseDelay 获取重连基础等待时间
+func GetWsReconnectBaseDelay() time.Duration {
+	if n := GetUserConfig().WsReconnectBaseDelay; n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	return time.Second
+}
+
+// GetWsReconnectMaxDelay 获取重连最大等待时间，不小于基础等待时间
+func GetWsReconnectMaxDelay() time.Duration {
+	maxDelay := 30 * time.Second
+	if n := GetUserConfig().WsReconnectMaxDelay; n > 0 {
+		maxDelay = time.Duration(n) * time.Second
+	}
+	return max(maxDelay, GetWsReconnectBaseDelay())
+}
+
+// GetAppHeartbeatMaxFailures 获取应用心跳最大连续失败次数
+func GetAppHeartbeatMaxFailures() int {
+	if n := GetUserConfig().AppHeartbeatMaxFailures; n > 0 {
+		return n
+	}
+	return 3
+}
+
+// GetRecordEvents 获取是否录制直播事件
+func GetRecordEvents() bool {
+	return GetUserConfig().RecordEvents
+}
+
+// GetDedupWindow 获取消息去重时间窗口
+func GetDedupWindow() time.Duration {
+	if n := GetUserConfig().DedupWindow; n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	return 600 * time.Second
+}
+
+// GetDedupCapacity 获取消息去重最多记录的消息数
+func GetDedupCapacity() int {
+	if n := GetUserConfig().DedupCapacity; n > 0 {
+		return n
+	}
+	return 10000
+}
+
+// GetWebhookAddr 获取本地Webhook监听地址
+func GetWebhookAddr() string {
+	return GetUserConfig().WebhookAddr
+}
+
+// GetWebhookToken 获取本地Webhook访问令牌
+func GetWebhookToken() string {
+	return GetUserConfig().WebhookToken
+}
+
+// GetTailFile 获取跟踪的事件文件
+func GetTailFile() string {
+	return GetUserConfig().TailFile
+}
+
+// GetGiftComboTimeout 获取礼物合并等待时间
+func GetGiftComboTimeout() time.Duration {
+	if n := GetUserConfig().GiftComboTimeout; n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	return 5 * time.Second
+}
+
+// GetLikeWindow 获取点赞合并窗口
+func GetLikeWindow() time.Duration {
+	if n := GetUserConfig().LikeWindow; n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	return 10 * time.Second
+}
+
+// GetLikeMinCount 获取点赞播报门槛
+func GetLikeMinCount() int {
+	if n := GetUserConfig().LikeMinCount; n > 0 {
+		return n
+	}
+	return 10
+}
+
+// GetLikeUserMinCount 获取单个用户的点赞播报门槛
+func GetLikeUserMinCount() int {
+	if n := GetUserConfig().LikeUserMinCount; n > 0 {
+		return n
+	}
+	return 3
+}
+
+// GetWelcomeCooldown 获取进房欢迎冷却时间
+func GetWelcomeCooldown() time.Duration {
+	if n := GetUserConfig().WelcomeCooldown; n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	return 600 * time.Second
+}
+
+// GetWelcomeMaxPerMinute 获取每分钟最多欢迎次数
+func GetWelcomeMaxPerMinute() int {
+	if n := GetUserConfig().WelcomeMaxPerMinute; n > 0 {
+		return n
+	}
+	return 10
+}
+
+// GetWelcomeBackDays 获取老观众回归天数
+func GetWelcomeBackDays() int {
+	if n := GetUserConfig().WelcomeBackDays; n > 0 {
+		return n
+	}
+	return 3
+}
+
+// GetEmojiMode 获取表情弹幕处理方式
+func GetEmojiMode() string {
+	switch mode := GetUserConfig().EmojiMode; mode {
+	case EmojiModeSkip, EmojiModeMerge:
+		return mode
+	default:
+		return EmojiModeRead
+	}
+}
+
+// GetEmojiMergeWindow 获取表情合并等待时间
+func GetEmojiMergeWindow() time.Duration {
+	if n := GetUserConfig().EmojiMergeWindow; n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	return 5 * time.Second
+}
+
+// GetVoicePreviewCooldown 获取试听音色冷却时间
+func GetVoicePreviewCooldown() time.Duration {
+	if n := GetUserConfig().VoicePreviewCooldown; n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	return 60 * time.Second
+}
+
+// GetEmojiName 从表情名称字典中查找表情名，依次按表情弹幕的文本和图片地址查找
+func GetEmojiName(text, imgURL string) (string, bool) {
+	names := GetUserConfig().EmojiNames
+	if name, ok := names[text]; ok && text != "" {
+		return name, true
+	}
+	if name, ok := names[imgURL]; ok && imgURL != "" {
+		return name, true
+	}
+	return "", false
+}
+
+// GetBlindBoxPrice 从盲盒单价表中查找盲盒单价
+func GetBlindBoxPrice(blindGiftID int) (int, bool) {
+	price, ok := GetUserConfig().BlindBoxPrices[strconv.Itoa(blindGiftID)]
+	return price, ok && price > 0
+}
+
 func IfFirstStart() bool {
 	return GetUserConfig().FirstStart
 }
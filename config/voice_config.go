@@ -14,6 +14,11 @@ type Voice struct {
 	VoiceType     string `json:"voice_type"`      //音色的Type号
 	Gender        string `json:"gender"`          //性别
 	ApiResourceID string `json:"api_resource_id"` // 火山引擎TTS，调用服务的资源信息 ID https://www.volcengine.com/docs/6561/1598757
+
+	Requires *VoiceRequirement `json:"requires,omitempty"` // 专属音色的使用条件，为空时所有观众都可以使用
+
+	SpeechRateOffset int `json:"-"` // 观众设置的语速偏移，叠加在全局语速上，由 user.GetUserVoice 填入
+	LoudnessRate     int `json:"-"` // 观众设置的音量偏移，在全局播放音量的基础上调整，由 user.GetUserVoice 填入
 }
 
 // Config 配置管理结构体
@@ -151,6 +156,19 @@ func GetVoiceByName(name string) *Voice {
 	return voice
 }
 
+// LookupVoiceByName 通过名称查找音色，不存在时返回false
+func LookupVoiceByName(name string) (*Voice, bool) {
+	voiceMutex.RLock()
+	defer voiceMutex.RUnlock()
+
+	config := GetVoiceConfig()
+	if config == nil || config.nameMap == nil {
+		return nil, false
+	}
+	voice, exists := config.nameMap[name]
+	return voice, exists
+}
+
 // GetVoiceByType 通过类型快速查找音色 - O(1) 时间复杂度
 func GetVoiceByType(voiceType string) *Voice {
 	voiceMutex.RLock()
@@ -168,7 +186,7 @@ func GetVoiceByType(voiceType string) *Voice {
 	return voice
 }
 
-// GetRandomVoice 获取随机音色
+// GetRandomVoice 获取随机音色，只从免费音色中选择，没有免费音色时从所有音色中选择
 func GetRandomVoice() *Voice {
 	voiceMutex.RLock()
 	defer voiceMutex.RUnlock()
@@ -178,8 +196,21 @@ func GetRandomVoice() *Voice {
 		return nil
 	}
 
-	randIndex := rand.Intn(len(config.Voices))
-	return &config.Voices[randIndex]
+	return randomFreeVoice(config.Voices)
+}
+
+// randomFreeVoice 从音色中随机选择一个免费音色，没有免费音色时从所有音色中选择
+func randomFreeVoice(voices []Voice) *Voice {
+	var free []int
+	for i := range voices {
+		if !voices[i].IsPremium() {
+			free = append(free, i)
+		}
+	}
+	if len(free) == 0 {
+		return &voices[rand.Intn(len(voices))]
+	}
+	return &voices[free[rand.Intn(len(free))]]
 }
 
 // GetMaleVoices 获取所有男性音色 - O(1) 时间复杂度
@@ -214,7 +245,7 @@ func GetFemaleVoices() []Voice {
 	return result
 }
 
-// GetRandomMaleVoice 获取随机男性音色 - O(1) 时间复杂度
+// GetRandomMaleVoice 获取随机男性音色，优先选择免费音色
 func GetRandomMaleVoice() *Voice {
 	voiceMutex.RLock()
 	defer voiceMutex.RUnlock()
@@ -224,11 +255,10 @@ func GetRandomMaleVoice() *Voice {
 		return nil
 	}
 
-	randIndex := rand.Intn(len(config.maleVoices))
-	return &config.maleVoices[randIndex]
+	return randomFreeVoice(config.maleVoices)
 }
 
-// GetRandomFemaleVoice 获取随机女性音色 - O(1) 时间复杂度
+// GetRandomFemaleVoice 获取随机女性音色，优先选择免费音色
 func GetRandomFemaleVoice() *Voice {
 	voiceMutex.RLock()
 	defer voiceMutex.RUnlock()
@@ -238,6 +268,5 @@ func GetRandomFemaleVoice() *Voice {
 		return nil
 	}
 
-	randIndex := rand.Intn(len(config.femaleVoices))
-	return &config.femaleVoices[randIndex]
+	return randomFreeVoice(config.femaleVoices)
 }
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// VoiceRequirement 专属音色的使用条件，满足其中任意一个即可使用，为0的条件不生效
+type VoiceRequirement struct {
+	GuardLevel     int `json:"guard_level,omitempty"`      // 大航海等级，1总督 2提督 3舰长，更高等级的大航海也可以使用
+	FansMedalLevel int `json:"fans_medal_level,omitempty"` // 本房间粉丝勋章的最低等级
+	GiftValue      int `json:"gift_value,omitempty"`       // 累计送出的付费礼物价值，1000 = 1元
+}
+
+// Supporter 观众的身份，用于判断能否使用专属音色
+type Supporter struct {
+	GuardLevel     int // 大航海等级，1总督 2提督 3舰长，0不是大航海
+	FansMedalLevel int // 本房间粉丝勋章等级，没有时为0
+	GiftValue      int // 累计送出的付费礼物价值，1000 = 1元
+}
+
+// guardNames 大航海等级名称
+var guardNames = map[int]string{1: "总督", 2: "提督", 3: "舰长"}
+
+// empty 是否没有任何条件
+func (r *VoiceRequirement) empty() bool {
+	return r == nil || (r.GuardLevel <= 0 && r.FansMedalLevel <= 0 && r.GiftValue <= 0)
+}
+
+// Met 观众是否满足任意一个条件
+func (r *VoiceRequirement) Met(s Supporter) bool {
+	if r.empty() {
+		return true
+	}
+	return (r.GuardLevel > 0 && s.GuardLevel > 0 && s.GuardLevel <= r.GuardLevel) ||
+		(r.FansMedalLevel > 0 && s.FansMedalLevel >= r.FansMedalLevel) ||
+		(r.GiftValue > 0 && s.GiftValue >= r.GiftValue)
+}
+
+// String 使用条件的说明，用于播报，如 "舰长及以上、20级粉丝勋章或累计送礼100元"
+func (r *VoiceRequirement) String() string {
+	if r.empty() {
+		return ""
+	}
+	var parts []string
+	if name, ok := guardNames[r.GuardLevel]; ok {
+		if r.GuardLevel > 1 {
+			name += "及以上"
+		}
+		parts = append(parts, name)
+	}
+	if r.FansMedalLevel > 0 {
+		parts = append(parts, fmt.Sprintf("%d级粉丝勋章", r.FansMedalLevel))
+	}
+	if r.GiftValue > 0 {
+		yuan := strconv.FormatFloat(float64(r.GiftValue)/1000, 'f', -1, 64)
+		parts = append(parts, fmt.Sprintf("累计送礼%s元", yuan))
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return strings.Join(parts[:len(parts)-1], "、") + "或" + parts[len(parts)-1]
+}
+
+// IsPremium 是否为专属音色
+func (v *Voice) IsPremium() bool {
+	return !v.Requires.empty()
+}
+
+// AvailableTo 观众能否使用该音色，免费音色所有观众都可以使用
+func (v *Voice) AvailableTo(s Supporter) bool {
+	return v.Requires.Met(s)
+}
+
+// RandomVoiceFor 从观众可以使用的音色中随机选择一个
+func RandomVoiceFor(s Supporter) *Voice {
+	voices := availableVoices(allVoices(), s)
+	if len(voices) == 0 {
+		return nil
+	}
+	return voices[rand.Intn(len(voices))]
+}
+
+// availableVoices 观众可以使用的音色，都不能使用时原样返回，由调用方说明使用条件
+func availableVoices(voices []*Voice, s Supporter) []*Voice {
+	if available := filterVoices(voices, func(v *Voice) bool { return v.AvailableTo(s) }); len(available) > 0 {
+		return available
+	}
+	return voices
+}
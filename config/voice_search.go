@@ -0,0 +1,224 @@
+package config
+
+import (
+	"math/rand"
+	"strings"
+	"unicode/utf8"
+)
+
+// VoiceMatch 音色搜索的匹配方式
+type VoiceMatch int
+
+const (
+	VoiceMatchNone     VoiceMatch = iota // 没有找到
+	VoiceMatchExact                      // 名称完全相同
+	VoiceMatchGender                     // 按性别随机，如 "男声"
+	VoiceMatchTag                        // 名称包含搜索词，有多个时随机，如 "萌"
+	VoiceMatchInitials                   // 拼音首字母，如 "wwxh"
+	VoiceMatchFuzzy                      // 名称相近，如打错一个字
+)
+
+// String 匹配方式名称，用于日志
+func (m VoiceMatch) String() string {
+	switch m {
+	case VoiceMatchExact:
+		return "名称"
+	case VoiceMatchGender:
+		return "性别"
+	case VoiceMatchTag:
+		return "标签"
+	case VoiceMatchInitials:
+		return "拼音首字母"
+	case VoiceMatchFuzzy:
+		return "近似名称"
+	default:
+		return "未找到"
+	}
+}
+
+// maxSearchCandidates 有多个相近的音色时最多提示的数量
+const maxSearchCandidates = 3
+
+// VoiceSearchResult 音色搜索结果
+type VoiceSearchResult struct {
+	Voice      *Voice     // 找到的音色，没有找到或有多个同样相近的音色时为空
+	Candidates []*Voice   // 有多个同样相近的音色时的候选，用于提示 "你是不是想找"
+	Match      VoiceMatch // 匹配方式
+	Distance   int        // 近似匹配时和音色名称的编辑距离
+}
+
+// genderKeywords 按性别筛选音色的关键词
+var genderKeywords = []struct {
+	word   string
+	gender string
+}{
+	{"男声", "male"}, {"男生", "male"}, {"男", "male"},
+	{"女声", "female"}, {"女生", "female"}, {"女", "female"},
+}
+
+// SearchVoice 按名称搜索音色，依次尝试完整名称、性别、名称包含的标签、拼音首字母和编辑距离
+// 性别可以和标签一起使用，如 "萌女声"，按性别和标签找到多个音色时随机选择一个
+func SearchVoice(query string) VoiceSearchResult {
+	return searchVoice(query, nil)
+}
+
+// SearchVoiceFor 按名称为观众搜索音色，按性别或标签随机选择时只选观众可以使用的音色
+// 指定了具体的专属音色，或者符合条件的都是专属音色时仍然返回该音色，由调用方检查能否使用
+func SearchVoiceFor(query string, s Supporter) VoiceSearchResult {
+	return searchVoice(query, func(voices []*Voice) []*Voice { return availableVoices(voices, s) })
+}
+
+// searchVoice 按名称搜索音色，narrow 用于缩小随机选择的范围，为空时从所有符合条件的音色中选择
+func searchVoice(query string, narrow func([]*Voice) []*Voice) VoiceSearchResult {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return VoiceSearchResult{}
+	}
+	if v, ok := LookupVoiceByName(query); ok {
+		return VoiceSearchResult{Voice: v, Match: VoiceMatchExact}
+	}
+
+	if narrow == nil {
+		narrow = func(voices []*Voice) []*Voice { return voices }
+	}
+	for _, k := range genderKeywords {
+		if query == k.word {
+			return pickRandom(narrow(VoicesWithTag(query)), VoiceMatchGender)
+		}
+	}
+	if tagged := VoicesWithTag(query); len(tagged) > 0 {
+		return pickRandom(narrow(tagged), VoiceMatchTag)
+	}
+
+	voices := allVoices()
+	if initials := pinyinInitials(query); initials == query {
+		matched := filterVoices(voices, func(v *Voice) bool { return pinyinInitials(v.Name) == initials })
+		if res := pickUnique(matched, VoiceMatchInitials); res.Match != VoiceMatchNone {
+			return res
+		}
+	}
+
+	return searchFuzzy(voices, query)
+}
+
+// VoicesWithTag 按标签筛选音色，标签为空时返回所有音色，同名的音色只保留一个
+// 标签可以是性别（如 "女声"）、名称中的字（如 "萌"）或两者组合（如 "萌女声"）
+func VoicesWithTag(tag string) []*Voice {
+	tag = strings.TrimSpace(tag)
+	voices := uniqueVoices(allVoices())
+	if tag == "" {
+		return voices
+	}
+	for _, k := range genderKeywords {
+		if tag == k.word {
+			return filterVoices(voices, func(v *Voice) bool { return v.Gender == k.gender })
+		}
+	}
+	if tagged := filterVoices(voices, func(v *Voice) bool { return strings.Contains(v.Name, tag) }); len(tagged) > 0 {
+		return tagged
+	}
+	for _, k := range genderKeywords {
+		if rest := strings.TrimSpace(strings.Replace(tag, k.word, "", 1)); rest != tag {
+			return filterVoices(voices, func(v *Voice) bool { return v.Gender == k.gender && strings.Contains(v.Name, rest) })
+		}
+	}
+	return nil
+}
+
+// searchFuzzy 按编辑距离查找名称相近的音色，允许的距离随名称长度增加
+func searchFuzzy(voices []*Voice, query string) VoiceSearchResult {
+	limit := max(1, utf8.RuneCountInString(query)/3)
+	best, closest := limit, []*Voice(nil)
+	for _, v := range voices {
+		d := editDistance(query, v.Name)
+		switch {
+		case d > limit:
+			continue
+		case d < best:
+			best, closest = d, []*Voice{v}
+		case d == best:
+			closest = append(closest, v)
+		}
+	}
+	res := pickUnique(closest, VoiceMatchFuzzy)
+	if res.Match != VoiceMatchNone {
+		res.Distance = best
+	}
+	return res
+}
+
+// pickUnique 只有一个音色时返回该音色，有多个时返回候选，同名的音色只算一个
+func pickUnique(voices []*Voice, match VoiceMatch) VoiceSearchResult {
+	voices = uniqueVoices(voices)
+	switch len(voices) {
+	case 0:
+		return VoiceSearchResult{}
+	case 1:
+		return VoiceSearchResult{Voice: voices[0], Match: match}
+	default:
+		return VoiceSearchResult{Candidates: voices[:min(len(voices), maxSearchCandidates)], Match: match}
+	}
+}
+
+// pickRandom 从音色中随机选择一个
+func pickRandom(voices []*Voice, match VoiceMatch) VoiceSearchResult {
+	if len(voices) == 0 {
+		return VoiceSearchResult{}
+	}
+	return VoiceSearchResult{Voice: voices[rand.Intn(len(voices))], Match: match}
+}
+
+// allVoices 所有音色
+func allVoices() []*Voice {
+	voices := GetVoices()
+	result := make([]*Voice, len(voices))
+	for i := range voices {
+		result[i] = &voices[i]
+	}
+	return result
+}
+
+// uniqueVoices 去掉同名的音色
+func uniqueVoices(voices []*Voice) []*Voice {
+	seen := make(map[string]bool)
+	return filterVoices(voices, func(v *Voice) bool {
+		if seen[v.Name] {
+			return false
+		}
+		seen[v.Name] = true
+		return true
+	})
+}
+
+// filterVoices 筛选音色
+func filterVoices(voices []*Voice, keep func(v *Voice) bool) []*Voice {
+	var result []*Voice
+	for _, v := range voices {
+		if keep(v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// editDistance 两个字符串按字符计算的编辑距离
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
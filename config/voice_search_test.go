@@ -0,0 +1,73 @@
+package config
+
+import "testing"
+
+func TestPinyinInitials(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"湾湾小何", "wwxh"},
+		{"冷酷哥哥", "lkgg"},
+		{"Tina老师", "tinals"},
+		{"WWXH", "wwxh"},
+		{"女朋友", "npy"},
+		{"换个游戏吧", "hgyxb"},
+		{"123", "123"},
+		{"，！", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := pinyinInitials(tt.in); got != tt.want {
+			t.Errorf("pinyinInitials(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSearchFuzzy(t *testing.T) {
+	tests := []struct {
+		query     string
+		wantVoice string // 为空时不应找到唯一的音色
+		wantMatch VoiceMatch
+	}{
+		{"湾湾小河", "湾湾小何", VoiceMatchFuzzy},
+		{"冷酷哥", "冷酷哥哥", VoiceMatchFuzzy},
+		{"女朋友", "", VoiceMatchNone}, // 和 "女雷神" 相差两个字，超过允许的距离
+		{"个游戏吧", "", VoiceMatchNone},
+		{"一下", "", VoiceMatchNone},
+	}
+	voices := allVoices()
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			res := searchFuzzy(voices, tt.query)
+			if res.Match != tt.wantMatch {
+				t.Fatalf("Match = %v, want %v (候选 %d 个)", res.Match, tt.wantMatch, len(res.Candidates))
+			}
+			if tt.wantVoice == "" {
+				if res.Voice != nil {
+					t.Fatalf("不应找到音色，got %s", res.Voice.Name)
+				}
+				return
+			}
+			if res.Voice == nil || res.Voice.Name != tt.wantVoice {
+				t.Fatalf("Voice = %v, want %s", res.Voice, tt.wantVoice)
+			}
+		})
+	}
+}
+
+func TestSearchFuzzyWithinLimit(t *testing.T) {
+	for _, query := range []string{"女朋友", "湾湾小河", "冷酷哥", "换个游戏吧", "今天吃什么好呢"} {
+		res := searchFuzzy(allVoices(), query)
+		limit := max(1, len([]rune(query))/3)
+		found := append([]*Voice{res.Voice}, res.Candidates...)
+		for _, v := range found {
+			if v == nil {
+				continue
+			}
+			if d := editDistance(query, v.Name); d > limit {
+				t.Errorf("searchFuzzy(%q) 返回了距离 %d 的 %s，超过限制 %d", query, d, v.Name, limit)
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package dedup 按消息唯一id去重，避免重连或多包帧导致同一条礼物、弹幕被重复播报
+package dedup
+
+import (
+	"sync"
+	"time"
+)
+
+// Stats 去重统计
+type Stats struct {
+	Hits      int64 // 被判定为重复而丢弃的消息数
+	Misses    int64 // 首次出现的消息数
+	Evictions int64 // 因超出容量被提前淘汰的记录数
+	Size      int   // 当前记录数
+}
+
+// entry 一条去重记录
+type entry struct {
+	key string
+	at  time.Time
+}
+
+// Store 有容量上限、按时间过期的去重记录
+type Store struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	seen     map[string]time.Time
+	order    []entry // 按记录时间排序，用于过期和淘汰
+	stats    Stats
+}
+
+// NewStore 创建去重记录，capacity 为最多保存的记录数，ttl 为记录的有效期
+func NewStore(capacity int, ttl time.Duration) *Store {
+	return &Store{
+		capacity: capacity,
+		ttl:      ttl,
+		seen:     make(map[string]time.Time),
+	}
+}
+
+// Seen 检查 key 是否在有效期内出现过，首次出现时记录下来并返回 false
+func (s *Store) Seen(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.expireLocked(now)
+
+	if _, ok := s.seen[key]; ok {
+		s.stats.Hits++
+		return true
+	}
+
+	s.stats.Misses++
+	s.seen[key] = now
+	s.order = append(s.order, entry{key: key, at: now})
+
+	// 超出容量时淘汰最早的记录
+	for s.capacity > 0 && len(s.order) > s.capacity {
+		delete(s.seen, s.order[0].key)
+		s.order = s.order[1:]
+		s.stats.Evictions++
+	}
+	return false
+}
+
+// Stats 返回去重统计
+func (s *Store) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expireLocked(time.Now())
+	stats := s.stats
+	stats.Size = len(s.seen)
+	return stats
+}
+
+// Reset 清空所有记录和统计
+func (s *Store) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seen = make(map[string]time.Time)
+	s.order = nil
+	s.stats = Stats{}
+}
+
+// expireLocked 清理过期的记录（调用前需要加锁）
+func (s *Store) expireLocked(now time.Time) {
+	if s.ttl <= 0 {
+		return
+	}
+	expired := 0
+	for expired < len(s.order) && now.Sub(s.order[expired].at) > s.ttl {
+		delete(s.seen, s.order[expired].key)
+		expired++
+	}
+	if expired > 0 {
+		s.order = s.order[expired:]
+	}
+}
@@ -0,0 +1,94 @@
+package eventsource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/response"
+)
+
+// ManualUName 手动弹幕未指定昵称时使用的昵称
+const ManualUName = "系统公告"
+
+// manualSeq 手动弹幕的序号，用于生成唯一的msg_id
+var manualSeq atomic.Int64
+
+// Manual 手动注入消息的事件来源，供主播工具、房管和测试使用
+type Manual struct {
+	ch chan []byte
+}
+
+// NewManual 创建手动事件来源
+func NewManual() *Manual {
+	return &Manual{ch: make(chan []byte, 64)}
+}
+
+// Name 事件来源名称
+func (m *Manual) Name() string {
+	return SourceManual
+}
+
+// Run 把注入的消息写入事件流
+func (m *Manual) Run(ctx context.Context, emit func(data []byte) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case data := <-m.ch:
+			if err := emit(data); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Inject 注入一条原始消息，队列已满时返回错误
+func (m *Manual) Inject(data []byte) error {
+	select {
+	case m.ch <- data:
+		return nil
+	default:
+		return fmt.Errorf("手动消息队列已满")
+	}
+}
+
+// Danmaku 注入一条手动弹幕，与真实弹幕走相同的语音和LLM流程
+func (m *Manual) Danmaku(uname, msg string) error {
+	data, err := NewDanmaku(uname, msg)
+	if err != nil {
+		return err
+	}
+	return m.Inject(data)
+}
+
+// manualOpenID 手动弹幕用户的open_id，不同用户名的禁言、冷却和统计互不影响
+func manualOpenID(uname string) string {
+	return SourceManual + ":" + uname
+}
+
+// NewDanmaku 生成一条 LIVE_OPEN_PLATFORM_DM 消息，uname 为空时使用 ManualUName
+func NewDanmaku(uname, msg string) ([]byte, error) {
+	msg = strings.TrimSpace(msg)
+	if msg == "" {
+		return nil, fmt.Errorf("弹幕内容不能为空")
+	}
+	if uname = strings.TrimSpace(uname); uname == "" {
+		uname = ManualUName
+	}
+
+	now := time.Now()
+	return json.Marshal(response.DanmakuMessage{
+		Cmd: "LIVE_OPEN_PLATFORM_DM",
+		Data: response.DanmakuData{
+			OpenID:    manualOpenID(uname),
+			UName:     uname,
+			Msg:       msg,
+			MsgID:     fmt.Sprintf("%s-%d-%d", SourceManual, now.UnixNano(), manualSeq.Add(1)),
+			Timestamp: now.Unix(),
+		},
+	})
+}
@@ -0,0 +1,31 @@
+package eventsource
+
+import "github.com/CoffeeSwt/bilibili-tts-chat/config"
+
+// Options 可选事件来源的配置，字段为空时不创建对应的事件来源
+type Options struct {
+	WebhookAddr  string // 本地Webhook监听地址
+	WebhookToken string // 本地Webhook访问令牌
+	TailFile     string // 跟踪的文件路径
+}
+
+// DefaultOptions 从用户配置读取可选事件来源
+func DefaultOptions() Options {
+	return Options{
+		WebhookAddr:  config.GetWebhookAddr(),
+		WebhookToken: config.GetWebhookToken(),
+		TailFile:     config.GetTailFile(),
+	}
+}
+
+// Sources 根据配置创建事件来源
+func (o Options) Sources() []Source {
+	var sources []Source
+	if o.WebhookAddr != "" {
+		sources = append(sources, &Webhook{Addr: o.WebhookAddr, Token: o.WebhookToken})
+	}
+	if o.TailFile != "" {
+		sources = append(sources, &Tail{Path: o.TailFile})
+	}
+	return sources
+}
@@ -0,0 +1,145 @@
+// Package eventsource 直播事件来源，开放平台长连、本地Webhook、文件跟踪和手动弹幕
+// 都写入同一个事件流，按到达顺序逐条交给消息处理器
+package eventsource
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+)
+
+// 内置事件来源名称
+const (
+	SourceWebsocket = "websocket" // 开放平台长连
+	SourceWebhook   = "webhook"   // 本地HTTP接口
+	SourceTail      = "tail"      // 文件跟踪
+	SourceManual    = "manual"    // 手动弹幕
+)
+
+// Event 事件流中的一条原始消息
+type Event struct {
+	Source     string    // 事件来源名称
+	ReceivedAt time.Time // 进入事件流的时间
+	Data       []byte    // 原始消息，格式与开放平台推送的消息相同
+}
+
+// Source 事件来源，Run 阻塞运行直到上下文取消或出错，收到的消息通过 emit 写入事件流
+type Source interface {
+	Name() string
+	Run(ctx context.Context, emit func(data []byte) error) error
+}
+
+// Stream 合并多个事件来源的事件流，所有消息由同一个goroutine按到达顺序处理
+type Stream struct {
+	mu      sync.Mutex
+	handle  func(data []byte) error
+	events  chan Event
+	sources []Source
+	ctx     context.Context // Start 之后有效
+	done    chan struct{}   // 事件流停止后关闭
+	wg      sync.WaitGroup
+}
+
+// NewStream 创建事件流，handle 为消息处理函数，一般为 MessageHandler.HandleMessage
+func NewStream(handle func(data []byte) error) *Stream {
+	return &Stream{
+		handle: handle,
+		events: make(chan Event, 1024),
+		done:   make(chan struct{}),
+	}
+}
+
+// Add 添加事件来源，事件流已启动时立即运行
+func (s *Stream) Add(src Source) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sources = append(s.sources, src)
+	if s.ctx != nil {
+		s.run(src)
+	}
+}
+
+// Start 启动事件流和所有事件来源，上下文取消后停止
+func (s *Stream) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx != nil {
+		return fmt.Errorf("事件流已经启动")
+	}
+	s.ctx = ctx
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(s.done)
+		s.dispatchLoop(ctx)
+	}()
+
+	for _, src := range s.sources {
+		s.run(src)
+	}
+	return nil
+}
+
+// Wait 等待事件流和所有事件来源退出
+func (s *Stream) Wait() {
+	s.wg.Wait()
+}
+
+// Emit 把一条消息写入事件流，缓冲区满时等待，事件流停止后返回错误
+func (s *Stream) Emit(source string, data []byte) error {
+	event := Event{Source: source, ReceivedAt: time.Now(), Data: data}
+	select {
+	case s.events <- event:
+		return nil
+	case <-s.done:
+		return fmt.Errorf("事件流已停止")
+	}
+}
+
+// run 在新的goroutine中运行事件来源，调用时需持有 mu
+func (s *Stream) run(src Source) {
+	name := src.Name()
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		logger.Info(fmt.Sprintf("[EventSource] 事件来源 %s 已启动", name))
+		err := src.Run(s.ctx, func(data []byte) error {
+			return s.Emit(name, data)
+		})
+		if err != nil && s.ctx.Err() == nil {
+			logger.Error(fmt.Sprintf("[EventSource] 事件来源 %s 异常退出: %v", name, err))
+			return
+		}
+		logger.Info(fmt.Sprintf("[EventSource] 事件来源 %s 已停止", name))
+	}()
+}
+
+// dispatchLoop 按到达顺序逐条处理消息
+func (s *Stream) dispatchLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-s.events:
+			s.dispatch(event)
+		}
+	}
+}
+
+// dispatch 处理一条消息，处理函数的panic不会中断事件流
+func (s *Stream) dispatch(event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error(fmt.Sprintf("[EventSource] 处理来自 %s 的消息时发生panic: %v", event.Source, r))
+		}
+	}()
+	if err := s.handle(event.Data); err != nil {
+		logger.Error(fmt.Sprintf("[EventSource] 处理来自 %s 的消息失败: %v", event.Source, err))
+	}
+}
@@ -0,0 +1,129 @@
+package eventsource
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/recorder"
+)
+
+// Tail 跟踪文件新增行的事件来源，每行为一条开放平台格式的消息，也支持录制文件的行格式
+// 文件不存在时会等待创建，文件被截断后从头开始读取
+type Tail struct {
+	Path      string        // 文件路径
+	FromStart bool          // 是否从文件开头读取，默认只读取启动后新增的行
+	Interval  time.Duration // 检查新增内容的间隔，默认500毫秒
+}
+
+// Name 事件来源名称
+func (t *Tail) Name() string {
+	return SourceTail
+}
+
+// Run 持续读取新增行，直到上下文取消
+func (t *Tail) Run(ctx context.Context, emit func(data []byte) error) error {
+	interval := t.Interval
+	if interval <= 0 {
+		interval = 500 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var (
+		file    *os.File
+		reader  *bufio.Reader
+		offset  int64
+		partial []byte // 尚未读到换行符的内容
+	)
+	defer func() {
+		if file != nil {
+			file.Close()
+		}
+	}()
+
+	fromStart := t.FromStart
+	for {
+		if file == nil {
+			f, err := os.Open(t.Path)
+			if err == nil {
+				offset = 0
+				if !fromStart {
+					if offset, err = f.Seek(0, io.SeekEnd); err != nil {
+						f.Close()
+						return fmt.Errorf("定位文件末尾失败: %w", err)
+					}
+				}
+				file, reader, partial = f, bufio.NewReader(f), nil
+				logger.Info(fmt.Sprintf("[EventSource] 开始跟踪文件: %s", t.Path))
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("打开文件失败: %w", err)
+			}
+			// 启动后才创建的文件需要从头读取
+			fromStart = true
+		}
+
+		if file != nil {
+			if info, err := os.Stat(t.Path); err == nil && info.Size() < offset {
+				logger.Warn(fmt.Sprintf("[EventSource] 文件 %s 已被截断，从头读取", t.Path))
+				file.Seek(0, io.SeekStart)
+				reader.Reset(file)
+				offset, partial = 0, nil
+			}
+
+			for {
+				line, err := reader.ReadBytes('\n')
+				offset += int64(len(line))
+				if err != nil {
+					// 最后一行没有换行符，可能还没写完
+					partial = append(partial, line...)
+					break
+				}
+				if len(partial) > 0 {
+					line = append(partial, line...)
+					partial = nil
+				}
+				if data := parseTailLine(line); data != nil {
+					if err := emit(data); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// parseTailLine 解析一行内容，录制文件格式的行返回其中的原始消息，空行和无效行返回nil
+func parseTailLine(line []byte) []byte {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return nil
+	}
+
+	var record recorder.Record
+	if err := json.Unmarshal(line, &record); err != nil {
+		logger.Warn(fmt.Sprintf("[EventSource] 忽略无效的行: %v", err))
+		return nil
+	}
+	if len(record.Body) > 0 {
+		return record.Body
+	}
+	if err := checkMessage(line); err != nil {
+		logger.Warn(fmt.Sprintf("[EventSource] 忽略无效的行: %v", err))
+		return nil
+	}
+	return line
+}
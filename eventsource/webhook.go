@@ -0,0 +1,169 @@
+package eventsource
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/response"
+)
+
+// Webhook 本地HTTP事件来源
+//
+//	POST /event    请求体为一条开放平台格式的消息，或消息数组
+//	POST /danmaku  请求体为 {"uname": "...", "msg": "..."}，生成一条手动弹幕
+//
+// 设置了 Token 时，请求需要携带 Authorization: Bearer <Token> 请求头
+// /event 可以伪造房管和主播的消息，所以没有设置 Token 时只能监听本机地址
+type Webhook struct {
+	Addr  string // 监听地址，如 127.0.0.1:8090
+	Token string // 访问令牌，为空时不校验，只能监听本机地址
+}
+
+// webhookDanmaku 手动弹幕请求
+type webhookDanmaku struct {
+	UName string `json:"uname"`
+	Msg   string `json:"msg"`
+}
+
+// Name 事件来源名称
+func (w *Webhook) Name() string {
+	return SourceWebhook
+}
+
+// Run 启动HTTP服务，上下文取消后关闭
+func (w *Webhook) Run(ctx context.Context, emit func(data []byte) error) error {
+	if w.Token == "" && !isLoopback(w.Addr) {
+		return fmt.Errorf("Webhook监听 %s 不是本机地址，需要设置访问令牌", w.Addr)
+	}
+	ln, err := net.Listen("tcp", w.Addr)
+	if err != nil {
+		return fmt.Errorf("Webhook监听 %s 失败: %w", w.Addr, err)
+	}
+	logger.Info(fmt.Sprintf("[EventSource] Webhook已监听: http://%s", ln.Addr()))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/event", w.authorize(func(rw http.ResponseWriter, r *http.Request, body []byte) {
+		messages, err := splitMessages(body)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, msg := range messages {
+			if err := emit(msg); err != nil {
+				http.Error(rw, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	mux.HandleFunc("/danmaku", w.authorize(func(rw http.ResponseWriter, r *http.Request, body []byte) {
+		var req webhookDanmaku
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(rw, fmt.Sprintf("解析请求失败: %v", err), http.StatusBadRequest)
+			return
+		}
+		data, err := NewDanmaku(req.UName, req.Msg)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := emit(data); err != nil {
+			http.Error(rw, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// authorize 校验请求方法和令牌，并读取请求体
+func (w *Webhook) authorize(next func(rw http.ResponseWriter, r *http.Request, body []byte)) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(rw, "只支持POST请求", http.StatusMethodNotAllowed)
+			return
+		}
+		if w.Token != "" {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(w.Token)) != 1 {
+				http.Error(rw, "令牌无效", http.StatusUnauthorized)
+				return
+			}
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(rw, fmt.Sprintf("读取请求失败: %v", err), http.StatusBadRequest)
+			return
+		}
+		next(rw, r, body)
+	}
+}
+
+// isLoopback 监听地址是否只接受本机连接，主机名为空时监听所有网卡
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// splitMessages 把请求体拆成单条消息，支持单条消息和消息数组
+func splitMessages(body []byte) ([][]byte, error) {
+	trimmed := strings.TrimSpace(string(body))
+	if strings.HasPrefix(trimmed, "[") {
+		var raws []json.RawMessage
+		if err := json.Unmarshal(body, &raws); err != nil {
+			return nil, fmt.Errorf("解析消息数组失败: %w", err)
+		}
+		messages := make([][]byte, 0, len(raws))
+		for _, raw := range raws {
+			if err := checkMessage(raw); err != nil {
+				return nil, err
+			}
+			messages = append(messages, raw)
+		}
+		return messages, nil
+	}
+	if err := checkMessage(body); err != nil {
+		return nil, err
+	}
+	return [][]byte{body}, nil
+}
+
+// checkMessage 校验消息是否为带cmd字段的JSON对象
+func checkMessage(data []byte) error {
+	var msg response.LiveMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return fmt.Errorf("解析消息失败: %w", err)
+	}
+	if msg.Cmd == "" {
+		return fmt.Errorf("消息缺少cmd字段")
+	}
+	return nil
+}
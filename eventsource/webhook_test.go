@@ -0,0 +1,57 @@
+package eventsource
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/response"
+)
+
+func TestIsLoopback(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"127.0.0.1:8090", true},
+		{"localhost:8090", true},
+		{"[::1]:8090", true},
+		{":8090", false},
+		{"0.0.0.0:8090", false},
+		{"192.168.1.10:8090", false},
+		{"8090", false},
+	}
+	for _, tt := range tests {
+		if got := isLoopback(tt.addr); got != tt.want {
+			t.Errorf("isLoopback(%q) = %t, want %t", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestWebhookRequiresTokenOffLoopback(t *testing.T) {
+	w := &Webhook{Addr: "0.0.0.0:0"}
+	if err := w.Run(context.Background(), nil); err == nil {
+		t.Fatal("没有令牌时不应监听非本机地址")
+	}
+}
+
+func TestNewDanmakuOpenIDPerName(t *testing.T) {
+	openID := func(uname string) string {
+		data, err := NewDanmaku(uname, "你好")
+		if err != nil {
+			t.Fatalf("NewDanmaku: %v", err)
+		}
+		var msg response.DanmakuMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatalf("解析消息失败: %v", err)
+		}
+		return msg.Data.OpenID
+	}
+
+	if a, b := openID("小明"), openID("小红"); a == b {
+		t.Errorf("不同用户名的open_id相同: %s", a)
+	}
+	if a, b := openID("小明"), openID(" 小明 "); a != b {
+		t.Errorf("同一用户名的open_id不同: %s, %s", a, b)
+	}
+}
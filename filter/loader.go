@@ -0,0 +1,85 @@
+package filter
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/config"
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+)
+
+// FileName 过滤规则文件名，从工作目录向上查找，没有时不过滤
+const FileName = "filter_rules.yaml"
+
+// reloadInterval 检查规则文件是否修改的间隔
+const reloadInterval = 2 * time.Second
+
+var rules loader
+
+// loader 加载过滤规则文件，文件修改后自动重新加载
+type loader struct {
+	mu      sync.Mutex
+	checked time.Time // 上次检查文件的时间
+	path    string
+	modTime time.Time
+	rules   *Ruleset // 没有规则文件时为空
+}
+
+// current 返回当前的过滤规则，距上次检查超过 reloadInterval 时检查文件是否修改
+func (l *loader) current() *Ruleset {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if time.Since(l.checked) < reloadInterval {
+		return l.rules
+	}
+	l.checked = time.Now()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return l.rules
+	}
+	path, ok := config.FindFileUpwardsProxy(wd, FileName)
+	if !ok {
+		if l.rules != nil {
+			logger.Info("[内容过滤] 规则文件已删除，不再过滤")
+		}
+		l.path, l.modTime, l.rules = "", time.Time{}, nil
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil || (path == l.path && info.ModTime().Equal(l.modTime)) {
+		return l.rules
+	}
+
+	// 无论成功与否都记录修改时间，文件出错时不会反复解析
+	l.path, l.modTime = path, info.ModTime()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[内容过滤] 读取规则文件失败: %v", err))
+		return l.rules
+	}
+	rs, err := Parse(data)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[内容过滤] %v，继续使用之前的规则", err))
+		return l.rules
+	}
+	l.rules = rs
+	logger.Info(fmt.Sprintf("[内容过滤] 已加载 %d 条过滤规则: %s", len(rs.Rules), path))
+	return l.rules
+}
+
+// Apply 用规则文件中的规则过滤消息，没有规则文件时原样返回
+// 被房管禁言的用户发送的弹幕和付费留言直接丢弃，mutes 为空时不检查禁言
+func Apply(in Input, mutes *MuteList) Result {
+	if in.Event != "" && mutes != nil && mutes.IsMuted(in.OpenID, in.UName) {
+		return Result{Dropped: true, Reasons: []string{"房管禁言"}}
+	}
+	rs := rules.current()
+	if rs == nil {
+		return Result{Text: in.Text}
+	}
+	return rs.Apply(in)
+}
@@ -0,0 +1,102 @@
+package filter
+
+import (
+	"sync"
+	"time"
+)
+
+// MuteList 一个直播间的禁言名单，房管按用户名禁言，用户再次发言时改为按open_id记录，改名后仍然有效
+type MuteList struct {
+	mu     sync.Mutex
+	byID   map[string]time.Time // open_id -> 禁言结束时间
+	byName map[string]time.Time // 用户名 -> 禁言结束时间，还不知道open_id的禁言
+	names  map[string]string    // 用户名 -> open_id，禁言期间见过的用户
+}
+
+// NewMuteList 创建禁言名单
+func NewMuteList() *MuteList {
+	return &MuteList{
+		byID:   make(map[string]time.Time),
+		byName: make(map[string]time.Time),
+		names:  make(map[string]string),
+	}
+}
+
+// Mute 禁言用户一段时间，期间该用户的弹幕和付费留言不再播报
+func (m *MuteList) Mute(uname string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	until := time.Now().Add(d)
+	if openID, ok := m.names[uname]; ok {
+		m.byID[openID] = until
+		return
+	}
+	m.byName[uname] = until
+}
+
+// Unmute 解除禁言，用户没有被禁言时返回false
+func (m *MuteList) Unmute(uname string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	until, ok := m.byName[uname]
+	delete(m.byName, uname)
+	if openID, seen := m.names[uname]; seen {
+		if u, muted := m.byID[openID]; muted {
+			until, ok = u, true
+		}
+		delete(m.byID, openID)
+		for name, id := range m.names {
+			if id == openID {
+				delete(m.names, name)
+			}
+		}
+	}
+	return ok && time.Now().Before(until)
+}
+
+// IsMuted 用户是否在禁言中，openID 为空时只按用户名判断
+func (m *MuteList) IsMuted(openID, uname string) bool {
+	if openID == "" && uname == "" {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if until, ok := m.byName[uname]; ok && uname != "" {
+		delete(m.byName, uname)
+		if now.Before(until) {
+			if openID == "" {
+				m.byName[uname] = until
+				return true
+			}
+			// 知道open_id后改为按open_id禁言
+			if prev, ok := m.byID[openID]; !ok || prev.Before(until) {
+				m.byID[openID] = until
+			}
+		}
+	}
+	if openID == "" {
+		return false
+	}
+
+	until, ok := m.byID[openID]
+	if !ok {
+		return false
+	}
+	if now.After(until) {
+		delete(m.byID, openID)
+		for name, id := range m.names {
+			if id == openID {
+				delete(m.names, name)
+			}
+		}
+		return false
+	}
+	if uname != "" {
+		m.names[uname] = openID
+	}
+	return true
+}
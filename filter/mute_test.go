@@ -0,0 +1,51 @@
+package filter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMuteList(t *testing.T) {
+	room := NewMuteList()
+	other := NewMuteList()
+	room.Mute("小明", time.Minute)
+
+	if other.IsMuted("open-1", "小明") {
+		t.Error("禁言不应影响其他直播间")
+	}
+	if !room.IsMuted("", "小明") {
+		t.Error("不知道open_id时应按用户名禁言")
+	}
+	if !room.IsMuted("open-1", "小明") {
+		t.Error("小明 应在禁言中")
+	}
+	// 再次发言后按open_id记录，改名后仍然禁言
+	if !room.IsMuted("open-1", "小明改名了") {
+		t.Error("改名后应仍在禁言中")
+	}
+	if room.IsMuted("open-2", "路人") {
+		t.Error("路人 不应被禁言")
+	}
+
+	// 新旧名字都可以解除禁言
+	if !room.Unmute("小明改名了") {
+		t.Fatal("Unmute 应返回true")
+	}
+	if room.IsMuted("open-1", "小明") {
+		t.Error("解除后不应再禁言")
+	}
+	if room.Unmute("小明") {
+		t.Error("已解除的禁言 Unmute 应返回false")
+	}
+}
+
+func TestMuteListExpired(t *testing.T) {
+	m := NewMuteList()
+	m.Mute("小明", -time.Second)
+	if m.IsMuted("open-1", "小明") {
+		t.Error("过期的禁言不应生效")
+	}
+	if m.Unmute("小明") {
+		t.Error("过期的禁言 Unmute 应返回false")
+	}
+}
@@ -0,0 +1,321 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v2"
+)
+
+// 规则类型
+const (
+	TypeKeyword = "keyword" // 关键词
+	TypeRegex   = "regex"   // 正则表达式
+	TypeUser    = "user"    // 用户黑名单，按 open_id
+	TypeLevel   = "level"   // 最低粉丝勋章等级或大航海等级
+	TypeLength  = "length"  // 长度限制
+	TypeRepeat  = "repeat"  // 重复字符折叠
+)
+
+// 命中规则后的处理
+const (
+	ActionDrop    = "drop"    // 丢弃消息
+	ActionMask    = "mask"    // 用掩码替换命中的内容
+	ActionRewrite = "rewrite" // 改写命中的内容
+)
+
+// 规则适用的事件
+const (
+	EventDanmaku   = "danmaku"
+	EventSuperChat = "super_chat"
+)
+
+// Rule 一条过滤规则
+type Rule struct {
+	Name   string   `yaml:"name"`   // 规则名，用于日志，为空时使用类型
+	Type   string   `yaml:"type"`   // 规则类型
+	Action string   `yaml:"action"` // 命中后的处理，为空时使用规则类型的默认处理
+	Events []string `yaml:"events"` // 适用的事件，为空时对弹幕和付费留言生效，用户黑名单对所有消息生效
+
+	Keywords []string `yaml:"keywords"` // keyword: 关键词，不区分大小写
+	Patterns []string `yaml:"patterns"` // regex: 正则表达式
+	OpenIDs  []string `yaml:"open_ids"` // user: 黑名单用户的 open_id
+
+	MinFansMedalLevel int `yaml:"min_fans_medal_level"` // level: 最低粉丝勋章等级
+	MinGuardLevel     int `yaml:"min_guard_level"`      // level: 最低大航海等级，3舰长 2提督 1总督，满足任一等级要求即可
+
+	MinLength int `yaml:"min_length"` // length: 最少字数
+	MaxLength int `yaml:"max_length"` // length: 最多字数，改写时截断
+
+	MaxRepeat int `yaml:"max_repeat"` // repeat: 同一字符最多连续出现的次数，改写时折叠
+
+	Mask        string `yaml:"mask"`        // mask: 掩码字符，默认为 *
+	Replacement string `yaml:"replacement"` // rewrite: 替换的内容，正则可以使用 $1 引用分组
+
+	regexps []*regexp.Regexp // 编译后的正则，关键词规则合并为一个正则
+}
+
+// Input 待过滤的消息
+type Input struct {
+	Event          string // 事件类型，EventDanmaku 或 EventSuperChat，其他消息为空
+	OpenID         string
+	UName          string
+	Text           string
+	FansMedalLevel int
+	GuardLevel     int
+}
+
+// Result 过滤结果
+type Result struct {
+	Text    string   // 过滤后的文本
+	Dropped bool     // 消息被丢弃
+	Reasons []string // 命中的规则及原因
+}
+
+// Changed 文本是否被修改
+func (r Result) Changed(in Input) bool {
+	return !r.Dropped && r.Text != in.Text
+}
+
+// Ruleset 一组过滤规则，按顺序执行，命中丢弃规则后不再执行后面的规则
+type Ruleset struct {
+	Rules []*Rule `yaml:"rules"`
+}
+
+// Parse 解析YAML格式的规则文件并检查规则
+func Parse(data []byte) (*Ruleset, error) {
+	var rs Ruleset
+	if err := yaml.Unmarshal(data, &rs); err != nil {
+		return nil, fmt.Errorf("解析过滤规则失败: %v", err)
+	}
+	for i, r := range rs.Rules {
+		if r == nil {
+			return nil, fmt.Errorf("第%d条过滤规则为空", i+1)
+		}
+		if err := r.compile(); err != nil {
+			return nil, fmt.Errorf("第%d条过滤规则 %s 错误: %v", i+1, r.name(), err)
+		}
+	}
+	return &rs, nil
+}
+
+// compile 检查规则并设置默认值
+func (r *Rule) compile() error {
+	if r.Action == "" {
+		r.Action = r.defaultAction()
+	}
+	if r.Mask == "" {
+		r.Mask = "*"
+	}
+	switch r.Action {
+	case ActionDrop, ActionMask, ActionRewrite:
+	default:
+		return fmt.Errorf("未知的处理方式: %s", r.Action)
+	}
+
+	switch r.Type {
+	case TypeKeyword:
+		var quoted []string
+		for _, kw := range r.Keywords {
+			if kw != "" {
+				quoted = append(quoted, regexp.QuoteMeta(kw))
+			}
+		}
+		if len(quoted) == 0 {
+			return fmt.Errorf("没有关键词")
+		}
+		// 所有关键词合并为一个不区分大小写的正则
+		r.regexps = []*regexp.Regexp{regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))}
+	case TypeRegex:
+		if len(r.Patterns) == 0 {
+			return fmt.Errorf("没有正则表达式")
+		}
+		for _, p := range r.Patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return fmt.Errorf("正则表达式 %s 错误: %v", p, err)
+			}
+			r.regexps = append(r.regexps, re)
+		}
+	case TypeUser:
+		// 黑名单可以为空，需要时再添加
+	case TypeLevel:
+		if r.MinFansMedalLevel <= 0 && r.MinGuardLevel <= 0 {
+			return fmt.Errorf("没有设置最低等级")
+		}
+	case TypeLength:
+		if r.MinLength <= 0 && r.MaxLength <= 0 {
+			return fmt.Errorf("没有设置长度限制")
+		}
+		// 太短的消息改写为空内容会播报一句空话
+		if r.MinLength > 0 && r.Action == ActionRewrite && r.Replacement == "" {
+			return fmt.Errorf("min_length 改写时 replacement 不能为空，不需要改写时请使用 drop")
+		}
+	case TypeRepeat:
+		if r.MaxRepeat <= 0 {
+			return fmt.Errorf("max_repeat 必须大于0")
+		}
+	default:
+		return fmt.Errorf("未知的规则类型: %s", r.Type)
+	}
+	return nil
+}
+
+// defaultAction 规则的默认处理，关键词和正则默认打码，最多字数和重复字符默认改写，其他（包括最少字数）默认丢弃
+func (r *Rule) defaultAction() string {
+	switch r.Type {
+	case TypeKeyword, TypeRegex:
+		return ActionMask
+	case TypeLength:
+		if r.MinLength > 0 {
+			return ActionDrop
+		}
+		return ActionRewrite
+	case TypeRepeat:
+		return ActionRewrite
+	default:
+		return ActionDrop
+	}
+}
+
+// name 规则名，没有设置时使用类型
+func (r *Rule) name() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.Type
+}
+
+// Apply 按顺序执行所有规则
+func (rs *Ruleset) Apply(in Input) Result {
+	res := Result{Text: in.Text}
+	for _, r := range rs.Rules {
+		if !r.appliesTo(in.Event) {
+			continue
+		}
+		cur := in
+		cur.Text = res.Text
+		reason, text, hit := r.apply(cur)
+		if !hit {
+			continue
+		}
+		res.Reasons = append(res.Reasons, fmt.Sprintf("%s(%s)", r.name(), reason))
+		if r.Action == ActionDrop {
+			res.Dropped = true
+			return res
+		}
+		res.Text = text
+	}
+	return res
+}
+
+// appliesTo 规则是否适用于事件
+func (r *Rule) appliesTo(event string) bool {
+	if len(r.Events) > 0 {
+		return slices.Contains(r.Events, event)
+	}
+	return r.Type == TypeUser || event == EventDanmaku || event == EventSuperChat
+}
+
+// apply 执行一条规则，返回命中原因和处理后的文本
+func (r *Rule) apply(in Input) (reason, text string, hit bool) {
+	switch r.Type {
+	case TypeKeyword:
+		if kw := r.regexps[0].FindString(in.Text); kw != "" {
+			return "关键词 " + kw, r.regexps[0].ReplaceAllStringFunc(in.Text, r.replacement), true
+		}
+	case TypeRegex:
+		for _, re := range r.regexps {
+			if re.MatchString(in.Text) {
+				return "正则 " + re.String(), r.replaceRegexps(in.Text), true
+			}
+		}
+	case TypeUser:
+		if in.OpenID != "" && slices.Contains(r.OpenIDs, in.OpenID) {
+			return "黑名单用户 " + in.UName, r.replaceAll(in.Text), true
+		}
+	case TypeLevel:
+		if !r.levelOK(in) {
+			return fmt.Sprintf("等级不足 勋章%d级 大航海%d", in.FansMedalLevel, in.GuardLevel), r.replaceAll(in.Text), true
+		}
+	case TypeLength:
+		n := utf8.RuneCountInString(in.Text)
+		if r.MinLength > 0 && n < r.MinLength {
+			return fmt.Sprintf("少于%d个字", r.MinLength), r.replaceAll(in.Text), true
+		}
+		if r.MaxLength > 0 && n > r.MaxLength {
+			text := string([]rune(in.Text)[:r.MaxLength]) + r.Replacement
+			if r.Action == ActionMask {
+				text = r.replaceAll(in.Text)
+			}
+			return fmt.Sprintf("超过%d个字", r.MaxLength), text, true
+		}
+	case TypeRepeat:
+		if collapsed := collapseRepeats(in.Text, r.MaxRepeat); collapsed != in.Text {
+			text := collapsed
+			if r.Action == ActionMask {
+				text = r.replaceAll(in.Text)
+			}
+			return fmt.Sprintf("字符连续重复超过%d次", r.MaxRepeat), text, true
+		}
+	}
+	return "", "", false
+}
+
+// levelOK 粉丝勋章等级或大航海等级满足任一要求
+func (r *Rule) levelOK(in Input) bool {
+	if r.MinFansMedalLevel > 0 && in.FansMedalLevel >= r.MinFansMedalLevel {
+		return true
+	}
+	// 大航海等级数字越小越高，0为没有大航海
+	if r.MinGuardLevel > 0 && in.GuardLevel > 0 && in.GuardLevel <= r.MinGuardLevel {
+		return true
+	}
+	return false
+}
+
+// replaceRegexps 替换文本中匹配正则的内容，改写时支持 $1 引用分组
+func (r *Rule) replaceRegexps(text string) string {
+	for _, re := range r.regexps {
+		if r.Action == ActionRewrite {
+			text = re.ReplaceAllString(text, r.Replacement)
+		} else {
+			text = re.ReplaceAllStringFunc(text, r.replacement)
+		}
+	}
+	return text
+}
+
+// replaceAll 替换整段文本，用于不针对文本内容的规则
+func (r *Rule) replaceAll(text string) string {
+	return r.replacement(text)
+}
+
+// replacement 命中内容的替换结果，打码时每个字替换为一个掩码
+func (r *Rule) replacement(matched string) string {
+	if r.Action == ActionMask {
+		return strings.Repeat(r.Mask, utf8.RuneCountInString(matched))
+	}
+	return r.Replacement
+}
+
+// collapseRepeats 把连续重复超过 limit 次的字符折叠为 limit 个，如 "哈哈哈哈哈" -> "哈哈哈"
+func collapseRepeats(text string, limit int) string {
+	var b strings.Builder
+	var last rune
+	count := 0
+	for _, c := range text {
+		if count > 0 && c == last {
+			count++
+		} else {
+			last, count = c, 1
+		}
+		if count <= limit {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
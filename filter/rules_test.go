@@ -0,0 +1,114 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		yaml       string
+		wantErr    string // 为空时应解析成功
+		wantAction string
+	}{
+		{name: "关键词默认打码", yaml: "rules: [{type: keyword, keywords: [笨蛋]}]", wantAction: ActionMask},
+		{name: "最多字数默认改写", yaml: "rules: [{type: length, max_length: 10}]", wantAction: ActionRewrite},
+		{name: "最少字数默认丢弃", yaml: "rules: [{type: length, min_length: 2}]", wantAction: ActionDrop},
+		{name: "最少和最多字数默认丢弃", yaml: "rules: [{type: length, min_length: 2, max_length: 10}]", wantAction: ActionDrop},
+		{name: "最少字数改写为指定内容", yaml: "rules: [{type: length, min_length: 2, action: rewrite, replacement: 嗯}]", wantAction: ActionRewrite},
+		{name: "最少字数改写为空内容", yaml: "rules: [{type: length, min_length: 2, action: rewrite}]", wantErr: "replacement 不能为空"},
+		{name: "用户黑名单默认丢弃", yaml: "rules: [{type: user}]", wantAction: ActionDrop},
+		{name: "没有关键词", yaml: "rules: [{type: keyword}]", wantErr: "没有关键词"},
+		{name: "错误的正则", yaml: "rules: [{type: regex, patterns: ['(']}]", wantErr: "正则表达式"},
+		{name: "没有长度限制", yaml: "rules: [{type: length}]", wantErr: "没有设置长度限制"},
+		{name: "未知的处理方式", yaml: "rules: [{type: keyword, keywords: [a], action: ban}]", wantErr: "未知的处理方式"},
+		{name: "未知的规则类型", yaml: "rules: [{type: emoji}]", wantErr: "未知的规则类型"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs, err := Parse([]byte(tt.yaml))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want 包含 %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := rs.Rules[0].Action; got != tt.wantAction {
+				t.Errorf("Action = %s, want %s", got, tt.wantAction)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	rs, err := Parse([]byte(`
+rules:
+  - {type: keyword, keywords: [笨蛋]}
+  - {type: regex, patterns: ['加.{0,3}群'], action: drop}
+  - {type: regex, name: 号码, patterns: ['(\d{3})\d{4}'], action: rewrite, replacement: '${1}某某'}
+  - {type: user, open_ids: [bad]}
+  - {type: level, events: [super_chat], min_fans_medal_level: 3, min_guard_level: 3}
+  - {type: length, min_length: 2}
+  - {type: length, max_length: 10, replacement: 等等}
+  - {type: repeat, max_repeat: 3}
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		in          Input
+		wantText    string
+		wantDropped bool
+	}{
+		{name: "没有命中", in: Input{Event: EventDanmaku, Text: "主播好"}, wantText: "主播好"},
+		{name: "关键词打码", in: Input{Event: EventDanmaku, Text: "你个笨蛋"}, wantText: "你个**"},
+		{name: "正则丢弃", in: Input{Event: EventDanmaku, Text: "快来加我群"}, wantDropped: true},
+		{name: "正则改写引用分组", in: Input{Event: EventDanmaku, Text: "打1381234"}, wantText: "打138某某"},
+		{name: "黑名单用户", in: Input{Event: EventDanmaku, OpenID: "bad", Text: "主播好"}, wantDropped: true},
+		{name: "黑名单对其他消息生效", in: Input{OpenID: "bad"}, wantDropped: true},
+		{name: "其他消息不过滤文本", in: Input{Text: "笨"}, wantText: "笨"},
+		{name: "等级不足", in: Input{Event: EventSuperChat, Text: "主播好", FansMedalLevel: 2}, wantDropped: true},
+		{name: "勋章等级满足", in: Input{Event: EventSuperChat, Text: "主播好", FansMedalLevel: 3}, wantText: "主播好"},
+		{name: "大航海等级满足", in: Input{Event: EventSuperChat, Text: "主播好", GuardLevel: 1}, wantText: "主播好"},
+		{name: "等级规则只对付费留言生效", in: Input{Event: EventDanmaku, Text: "主播好"}, wantText: "主播好"},
+		{name: "太短丢弃", in: Input{Event: EventDanmaku, Text: "1"}, wantDropped: true},
+		{name: "太长截断", in: Input{Event: EventDanmaku, Text: "一二三四五六七八九十十一"}, wantText: "一二三四五六七八九十等等"},
+		{name: "重复字符折叠", in: Input{Event: EventDanmaku, Text: "哈哈哈哈哈"}, wantText: "哈哈哈"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := rs.Apply(tt.in)
+			if res.Dropped != tt.wantDropped {
+				t.Fatalf("Dropped = %v, want %v (原因: %v)", res.Dropped, tt.wantDropped, res.Reasons)
+			}
+			if !tt.wantDropped && res.Text != tt.wantText {
+				t.Errorf("Text = %q, want %q", res.Text, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestCollapseRepeats(t *testing.T) {
+	tests := []struct {
+		text  string
+		limit int
+		want  string
+	}{
+		{"哈哈哈哈哈", 3, "哈哈哈"},
+		{"哈哈哈", 3, "哈哈哈"},
+		{"666666啊啊啊啊", 2, "66啊啊"},
+		{"abab", 1, "abab"},
+		{"", 3, ""},
+	}
+	for _, tt := range tests {
+		if got := collapseRepeats(tt.text, tt.limit); got != tt.want {
+			t.Errorf("collapseRepeats(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+		}
+	}
+}
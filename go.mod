@@ -3,11 +3,12 @@ module github.com/CoffeeSwt/bilibili-tts-chat
 go 1.25.3
 
 require (
+	github.com/andybalholm/brotli v1.2.6
 	github.com/ebitengine/oto/v3 v3.4.0
 	github.com/gorilla/websocket v1.5.3
 	github.com/hajimehoshi/go-mp3 v0.3.4
-	github.com/monaco-io/request v1.0.16
 	github.com/wailsapp/wails/v2 v2.11.0
+	golang.org/x/text v0.22.0
 	gopkg.in/yaml.v2 v2.4.0
 )
 
@@ -38,5 +39,4 @@ require (
 	golang.org/x/crypto v0.33.0 // indirect
 	golang.org/x/net v0.35.0 // indirect
 	golang.org/x/sys v0.36.0 // indirect
-	golang.org/x/text v0.22.0 // indirect
 )
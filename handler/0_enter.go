@@ -3,7 +3,10 @@ package handler
 import (
 	"encoding/json"
 	"fmt"
+	"sync/atomic"
 
+	"github.com/CoffeeSwt/bilibili-tts-chat/config"
+	"github.com/CoffeeSwt/bilibili-tts-chat/dedup"
 	"github.com/CoffeeSwt/bilibili-tts-chat/handler/dm"
 	"github.com/CoffeeSwt/bilibili-tts-chat/handler/guard"
 	"github.com/CoffeeSwt/bilibili-tts-chat/handler/interaction_end"
@@ -15,16 +18,136 @@ import (
 	"github.com/CoffeeSwt/bilibili-tts-chat/handler/super_chat"
 	"github.com/CoffeeSwt/bilibili-tts-chat/handler/super_chat_del"
 	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/recorder"
 	"github.com/CoffeeSwt/bilibili-tts-chat/response"
+	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
 )
 
 // MessageHandler 消息处理器结构体
 type MessageHandler struct {
+	tasks            *task_manager.TaskManager // 事件写入的任务管理器
+	recorder         *recorder.Session         // 录制会话，为空时不录制
+	onInteractionEnd func(gameID string)       // 消息推送结束回调
+	dedup            *dedup.Store              // 按msg_id去重，长连重连后仍然有效
+	danmaku          *dm.Handler               // 弹幕处理，表情弹幕按用户合并
+	gifts            *send_gift.Aggregator     // 礼物连击合并
+	likes            *like.Aggregator          // 点赞合并
+	welcome          *live_room_enter.Welcomer // 进房欢迎冷却和限流
+	summary          *live_end.Summary         // 直播场次统计，下播时播报总结
+	registry         *Registry                 // 按消息类型注册的处理函数
+	metrics          *Metrics                  // 按消息类型统计
+	unknown          atomic.Int64              // 没有处理函数的消息数
 }
 
-// NewMessageHandler 创建新的消息处理器
-func NewMessageHandler() *MessageHandler {
-	return &MessageHandler{}
+// NewMessageHandler 创建新的消息处理器，tasks 为空时使用全局任务管理器
+func NewMessageHandler(tasks *task_manager.TaskManager) *MessageHandler {
+	if tasks == nil {
+		tasks = task_manager.GetInstance()
+	}
+	gifts := send_gift.NewAggregator(tasks)
+	h := &MessageHandler{
+		tasks:   tasks,
+		dedup:   dedup.NewStore(config.GetDedupCapacity(), config.GetDedupWindow()),
+		danmaku: dm.NewHandler(tasks),
+		gifts:   gifts,
+		likes:   like.NewAggregator(tasks),
+		welcome: live_room_enter.NewWelcomer(tasks),
+		summary: live_end.NewSummary(tasks, gifts.BlindBoxes()),
+		metrics: NewMetrics(),
+	}
+	h.registry = NewRegistry(h.handleUnknown)
+	h.registry.Use(
+		Logging(),
+		h.metrics.Middleware(),
+		h.record,                     // 录制原始消息，未开启录制时忽略
+		Dedup(h.dedup),               // 同一条消息可能因重连或多包帧重复到达，按msg_id只处理一次
+		ContentFilter(tasks.Mutes()), // 按过滤规则丢弃或改写观众发送的内容
+		h.observe,                    // 统计本场直播的数据，下播时生成总结，被过滤的消息不计入
+	)
+	h.registerDefaults()
+	return h
+}
+
+// registerDefaults 注册内置的消息处理函数
+func (h *MessageHandler) registerDefaults() {
+	h.registry.Register("LIVE_OPEN_PLATFORM_DM", func(msg *Message) error {
+		return h.danmaku.HandleDanmaku(msg.Data)
+	})
+	h.registry.Register("LIVE_OPEN_PLATFORM_SEND_GIFT", func(msg *Message) error {
+		return h.gifts.HandleGift(msg.Data)
+	})
+	h.registry.Register("LIVE_OPEN_PLATFORM_SUPER_CHAT", func(msg *Message) error {
+		return super_chat.HandleSuperChat(h.tasks, msg.Data)
+	})
+	h.registry.Register("LIVE_OPEN_PLATFORM_SUPER_CHAT_DEL", func(msg *Message) error {
+		return super_chat_del.HandleSuperChatDel(h.tasks, msg.Data)
+	})
+	h.registry.Register("LIVE_OPEN_PLATFORM_GUARD", func(msg *Message) error {
+		return guard.HandleGuard(h.tasks, msg.Data)
+	})
+	h.registry.Register("LIVE_OPEN_PLATFORM_LIKE", func(msg *Message) error {
+		return h.likes.HandleLike(msg.Data)
+	})
+	h.registry.Register("LIVE_OPEN_PLATFORM_LIVE_ROOM_ENTER", func(msg *Message) error {
+		return h.welcome.HandleRoomEnter(msg.Data)
+	})
+	h.registry.Register("LIVE_OPEN_PLATFORM_LIVE_START", func(msg *Message) error {
+		return live_start.HandleLiveStart(h.tasks, msg.Data)
+	})
+	h.registry.Register("LIVE_OPEN_PLATFORM_LIVE_END", func(msg *Message) error {
+		return h.summary.HandleLiveEnd(msg.Data)
+	})
+	h.registry.Register("LIVE_OPEN_PLATFORM_INTERACTION_END", func(msg *Message) error {
+		return interaction_end.HandleInteractionEnd(msg.Data, h.onInteractionEnd)
+	})
+}
+
+// Registry 返回消息处理注册表，可以在运行时注册新的消息类型、替换内置处理或添加中间件
+func (h *MessageHandler) Registry() *Registry {
+	return h.registry
+}
+
+// Close 停止消息处理器，丢弃还在合并中的表情、礼物和点赞
+func (h *MessageHandler) Close() {
+	h.danmaku.Stop()
+	h.gifts.Stop()
+	h.likes.Stop()
+}
+
+// GetStats 获取消息处理统计信息
+func (h *MessageHandler) GetStats() map[string]interface{} {
+	stats := h.dedup.Stats()
+	counts, failed := h.metrics.Counts()
+	return map[string]interface{}{
+		"dedup_hits":      stats.Hits,
+		"dedup_misses":    stats.Misses,
+		"dedup_evictions": stats.Evictions,
+		"dedup_size":      stats.Size,
+
+		"emojis_pending":      h.danmaku.Pending(),
+		"gift_combos_pending": h.gifts.Pending(),
+		"likes_pending":       h.likes.Pending(),
+		"welcomes_suppressed": h.welcome.Suppressed(),
+
+		"messages_by_cmd":  counts,
+		"messages_failed":  failed,
+		"messages_unknown": h.unknown.Load(),
+	}
+}
+
+// SetRecorder 设置录制会话，为空时停止录制
+func (h *MessageHandler) SetRecorder(session *recorder.Session) {
+	h.recorder = session
+}
+
+// SetAnchor 设置主播的open_id，主播发送的弹幕可以使用所有指令
+func (h *MessageHandler) SetAnchor(openID string) {
+	h.danmaku.SetAnchor(openID)
+}
+
+// SetInteractionEndCallback 设置消息推送结束回调，用于重新建立场次
+func (h *MessageHandler) SetInteractionEndCallback(callback func(gameID string)) {
+	h.onInteractionEnd = callback
 }
 
 // HandleMessage 统一的消息分发函数
@@ -36,32 +159,43 @@ func (h *MessageHandler) HandleMessage(cmdData []byte) error {
 		return err
 	}
 
-	logger.Info(fmt.Sprintf("收到消息类型: %s", baseMsg.Cmd))
-
-	// 根据cmd类型分发到对应的处理函数
-	switch baseMsg.Cmd {
-	case "LIVE_OPEN_PLATFORM_DM":
-		return dm.HandleDanmaku(cmdData)
-	case "LIVE_OPEN_PLATFORM_SEND_GIFT":
-		return send_gift.HandleGift(cmdData)
-	case "LIVE_OPEN_PLATFORM_SUPER_CHAT":
-		return super_chat.HandleSuperChat(cmdData)
-	case "LIVE_OPEN_PLATFORM_SUPER_CHAT_DEL":
-		return super_chat_del.HandleSuperChatDel(cmdData)
-	case "LIVE_OPEN_PLATFORM_GUARD":
-		return guard.HandleGuard(cmdData)
-	case "LIVE_OPEN_PLATFORM_LIKE":
-		return like.HandleLike(cmdData)
-	case "LIVE_OPEN_PLATFORM_LIVE_ROOM_ENTER":
-		return live_room_enter.HandleRoomEnter(cmdData)
-	case "LIVE_OPEN_PLATFORM_LIVE_START":
-		return live_start.HandleLiveStart(cmdData)
-	case "LIVE_OPEN_PLATFORM_LIVE_END":
-		return live_end.HandleLiveEnd(cmdData)
-	case "LIVE_OPEN_PLATFORM_INTERACTION_END":
-		return interaction_end.HandleInteractionEnd(cmdData)
-	default:
-		logger.Warn(fmt.Sprintf("未知的消息类型: %s", baseMsg.Cmd))
-		return fmt.Errorf("未知的消息类型: %s", baseMsg.Cmd)
+	// 根据cmd类型分发到注册的处理函数
+	return h.registry.Dispatch(&Message{
+		Cmd:   baseMsg.Cmd,
+		MsgID: getMsgID(&baseMsg),
+		Data:  cmdData,
+	})
+}
+
+// record 录制中间件，录制所有收到的消息，包括重复消息
+func (h *MessageHandler) record(next HandlerFunc) HandlerFunc {
+	return func(msg *Message) error {
+		h.recorder.Write(msg.Cmd, msg.Data)
+		return next(msg)
+	}
+}
+
+// observe 场次统计中间件
+func (h *MessageHandler) observe(next HandlerFunc) HandlerFunc {
+	return func(msg *Message) error {
+		h.summary.Observe(msg.Cmd, msg.Data)
+		return next(msg)
+	}
+}
+
+// handleUnknown 未知消息的兜底处理，只记录日志，不影响其他消息
+func (h *MessageHandler) handleUnknown(msg *Message) error {
+	h.unknown.Add(1)
+	logger.Warn(fmt.Sprintf("未知的消息类型: %s", msg.Cmd))
+	return nil
+}
+
+// getMsgID 获取消息的唯一id，没有时返回空字符串
+func getMsgID(msg *response.LiveMessage) string {
+	data, ok := msg.Data.(map[string]any)
+	if !ok {
+		return ""
 	}
+	msgID, _ := data["msg_id"].(string)
+	return msgID
 }
@@ -0,0 +1,8 @@
+package common
+
+import "fmt"
+
+// SuperChatSourceID 付费留言播报的来源id，留言下线时用于撤回
+func SuperChatSourceID(messageID int) string {
+	return fmt.Sprintf("sc:%d", messageID)
+}
@@ -0,0 +1,219 @@
+package dm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/command"
+	"github.com/CoffeeSwt/bilibili-tts-chat/config"
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/reply_template"
+	"github.com/CoffeeSwt/bilibili-tts-chat/response"
+	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
+	"github.com/CoffeeSwt/bilibili-tts-chat/user"
+)
+
+// dmTypeEmoji 表情包弹幕
+const dmTypeEmoji = 1
+
+// EmojiEvent 表情弹幕回复模板的数据
+type EmojiEvent struct {
+	UName string
+	Name  string // 第一个表情名
+	Names string // 所有表情，如 "[打call] x2、[赞]"
+	Count int    // 表情数
+}
+
+// emojiGroup 一个用户合并中的表情
+type emojiGroup struct {
+	uname  string
+	order  []string       // 表情名，按第一次发送的顺序
+	counts map[string]int // 每种表情的数量
+	total  int
+}
+
+// Handler 弹幕处理器，文字弹幕直接处理，表情弹幕按配置播报、跳过或按用户合并
+type Handler struct {
+	mu      sync.Mutex
+	tm      *task_manager.TaskManager
+	groups  map[string]*emojiGroup
+	timers  map[string]*time.Timer
+	stopped bool
+	anchor  string // 主播的open_id，用于识别主播的指令权限
+}
+
+// NewHandler 创建弹幕处理器，播报写入 tm
+func NewHandler(tm *task_manager.TaskManager) *Handler {
+	return &Handler{
+		tm:     tm,
+		groups: make(map[string]*emojiGroup),
+		timers: make(map[string]*time.Timer),
+	}
+}
+
+// SetAnchor 设置主播的open_id
+func (h *Handler) SetAnchor(openID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.anchor = openID
+}
+
+// HandleDanmaku 处理弹幕消息
+// 这是弹幕消息的核心处理函数，负责解析和处理用户发送的弹幕
+func (h *Handler) HandleDanmaku(cmdData []byte) error {
+	var msg response.DanmakuMessage
+	if err := json.Unmarshal(cmdData, &msg); err != nil {
+		logger.Error(fmt.Sprintf("[DanmakuHandler] 解析弹幕消息失败: %v", err))
+		return err
+	}
+
+	// 大航海到期等原因不再满足专属音色的条件时换回免费音色
+	command.EnforceVoiceEligibility(h.tm, msg.Data.UName,
+		command.SupporterOf(msg.Data.OpenID, msg.Data.UName, msg.Data.GuardLevel, msg.Data.FansMedalLevel))
+
+	if msg.Data.DmType != dmTypeEmoji {
+		h.mu.Lock()
+		anchor := h.anchor
+		h.mu.Unlock()
+		return handleText(h.tm, &msg, anchor)
+	}
+
+	name := emojiName(msg.Data)
+	logger.Info(fmt.Sprintf("[表情弹幕] 用户: %s, 表情: %s, 原文: %s, 图片: %s",
+		msg.Data.UName, name, msg.Data.Msg, msg.Data.EmojiImgURL))
+
+	switch config.GetEmojiMode() {
+	case config.EmojiModeSkip:
+		return nil
+	case config.EmojiModeMerge:
+		h.add(msg.Data, name)
+	default:
+		g := &emojiGroup{uname: msg.Data.UName}
+		g.add(name)
+		announceEmoji(h.tm, g)
+	}
+	return nil
+}
+
+// add 把表情加入用户的分组，第一个表情开始计时，等待时间结束后合并播报
+func (h *Handler) add(data response.DanmakuData, name string) {
+	key := data.OpenID
+	if key == "" {
+		key = data.UName
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.stopped {
+		return
+	}
+	g, exists := h.groups[key]
+	if !exists {
+		g = &emojiGroup{uname: data.UName}
+		h.groups[key] = g
+		h.timers[key] = time.AfterFunc(config.GetEmojiMergeWindow(), func() { h.flush(key) })
+	}
+	g.add(name)
+}
+
+// flush 等待时间结束，播报用户的所有表情
+func (h *Handler) flush(key string) {
+	h.mu.Lock()
+	g, exists := h.groups[key]
+	if h.stopped || !exists {
+		h.mu.Unlock()
+		return
+	}
+	delete(h.groups, key)
+	delete(h.timers, key)
+	h.mu.Unlock()
+
+	announceEmoji(h.tm, g)
+}
+
+// Pending 返回等待合并播报的用户数
+func (h *Handler) Pending() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.groups)
+}
+
+// Stop 停止弹幕处理器，丢弃还没有播报的表情
+func (h *Handler) Stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.stopped = true
+	for key, t := range h.timers {
+		t.Stop()
+		delete(h.timers, key)
+		delete(h.groups, key)
+	}
+}
+
+// add 记录一个表情
+func (g *emojiGroup) add(name string) {
+	if g.counts == nil {
+		g.counts = make(map[string]int)
+	}
+	if _, exists := g.counts[name]; !exists {
+		g.order = append(g.order, name)
+	}
+	g.counts[name]++
+	g.total++
+}
+
+// names 所有表情，如 "[打call] x2、[赞]"
+func (g *emojiGroup) names() string {
+	parts := make([]string, 0, len(g.order))
+	for _, name := range g.order {
+		if g.counts[name] > 1 {
+			parts = append(parts, fmt.Sprintf("[%s] x%d", name, g.counts[name]))
+		} else {
+			parts = append(parts, "["+name+"]")
+		}
+	}
+	return strings.Join(parts, "、")
+}
+
+// announceEmoji 播报一个用户的表情
+func announceEmoji(tm *task_manager.TaskManager, g *emojiGroup) {
+	usingLLMReply := tm.UseLLMReply()
+	if usingLLMReply {
+		eventDescription := fmt.Sprintf("【表情】用户 %s 发送了表情：%s", g.uname, g.names())
+		if err := tm.AddText(eventDescription, task_manager.TextTypeNormal, user.GetUserVoice(g.uname)); err != nil {
+			logger.Error(fmt.Sprintf("[DanmakuHandler] 添加事件到任务管理器失败: %v", err))
+		}
+	} else {
+		reply, err := reply_template.Render(reply_template.EventEmoji, EmojiEvent{
+			UName: g.uname,
+			Name:  g.order[0],
+			Names: g.names(),
+			Count: g.total,
+		})
+		if err != nil {
+			logger.Error(fmt.Sprintf("[DanmakuHandler] 生成回复失败: %v", err))
+			return
+		}
+		if err := tm.AddText(reply, task_manager.TextTypeNoLLMReply, user.GetUserVoice(g.uname)); err != nil {
+			logger.Error(fmt.Sprintf("[DanmakuHandler] 添加事件到任务管理器失败: %v", err))
+		}
+	}
+}
+
+// emojiName 表情的播报名，优先使用表情名称字典，否则使用去掉方括号的弹幕文本
+func emojiName(data response.DanmakuData) string {
+	if name, ok := config.GetEmojiName(data.Msg, data.EmojiImgURL); ok {
+		return name
+	}
+	name := strings.TrimSpace(data.Msg)
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "["), "]")
+	if name == "" {
+		return "表情"
+	}
+	return name
+}
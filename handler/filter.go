@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/filter"
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+)
+
+// filterTextFields 需要过滤文本的消息类型和文本字段
+var filterTextFields = map[string]struct{ event, field string }{
+	"LIVE_OPEN_PLATFORM_DM":         {filter.EventDanmaku, "msg"},
+	"LIVE_OPEN_PLATFORM_SUPER_CHAT": {filter.EventSuperChat, "message"},
+}
+
+// ContentFilter 内容过滤中间件，按 filter_rules.yaml 中的规则过滤弹幕和付费留言的文本
+// 命中规则的消息被丢弃或改写后再交给处理函数，黑名单用户的其他消息也会被丢弃
+// mutes 为直播间的禁言名单，被禁言用户的弹幕和付费留言直接丢弃
+func ContentFilter(mutes *filter.MuteList) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(msg *Message) error {
+			filtered, err := filterMessage(msg, mutes)
+			if err != nil {
+				// 解析失败时不过滤，由处理函数报告错误
+				return next(msg)
+			}
+			if filtered == nil {
+				return nil
+			}
+			return next(filtered)
+		}
+	}
+}
+
+// filterMessage 过滤一条消息，消息被丢弃时返回空
+func filterMessage(msg *Message, mutes *filter.MuteList) (*Message, error) {
+	var raw struct {
+		Cmd  string         `json:"cmd"`
+		Data map[string]any `json:"data"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(msg.Data))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	fields := filterTextFields[msg.Cmd]
+	in := filter.Input{
+		Event:          fields.event,
+		OpenID:         stringField(raw.Data, "open_id"),
+		UName:          stringField(raw.Data, "uname"),
+		FansMedalLevel: intField(raw.Data, "fans_medal_level"),
+		GuardLevel:     intField(raw.Data, "guard_level"),
+	}
+	if user, ok := raw.Data["user_info"].(map[string]any); ok {
+		in.OpenID, in.UName = stringField(user, "open_id"), stringField(user, "uname")
+	}
+	if fields.field != "" {
+		in.Text = stringField(raw.Data, fields.field)
+	}
+
+	res := filter.Apply(in, mutes)
+	reasons := strings.Join(res.Reasons, "，")
+	if res.Dropped {
+		if in.Text != "" {
+			logger.Info(fmt.Sprintf("[内容过滤] 丢弃 %s 的消息 %s: %s, 原因: %s", in.UName, msg.Cmd, in.Text, reasons))
+		} else {
+			logger.Info(fmt.Sprintf("[内容过滤] 丢弃 %s 的消息 %s, 原因: %s", in.UName, msg.Cmd, reasons))
+		}
+		return nil, nil
+	}
+	if !res.Changed(in) {
+		return msg, nil
+	}
+
+	logger.Info(fmt.Sprintf("[内容过滤] 改写 %s 的消息 %s: %s -> %s, 原因: %s", in.UName, msg.Cmd, in.Text, res.Text, reasons))
+	raw.Data[fields.field] = res.Text
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &Message{Cmd: msg.Cmd, MsgID: msg.MsgID, Data: data}, nil
+}
+
+// stringField 读取消息中的字符串字段
+func stringField(data map[string]any, key string) string {
+	s, _ := data[key].(string)
+	return s
+}
+
+// intField 读取消息中的整数字段
+func intField(data map[string]any, key string) int {
+	n, ok := data[key].(json.Number)
+	if !ok {
+		return 0
+	}
+	v, _ := n.Int64()
+	return int(v)
+}
@@ -4,17 +4,24 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"github.com/CoffeeSwt/bilibili-tts-chat/config"
-	"github.com/CoffeeSwt/bilibili-tts-chat/handler/common"
+	"github.com/CoffeeSwt/bilibili-tts-chat/command"
 	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/reply_template"
 	"github.com/CoffeeSwt/bilibili-tts-chat/response"
 	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
 	"github.com/CoffeeSwt/bilibili-tts-chat/user"
 )
 
+// GuardEvent 大航海回复模板的数据
+type GuardEvent struct {
+	response.GuardData
+	GuardName string // 总督、提督、舰长
+	Duration  string // 时长描述，如 "一个月的"
+}
+
 // HandleGuard 处理大航海消息
 // 这是大航海消息的核心处理函数，负责解析和处理用户购买大航海的消息
-func HandleGuard(cmdData []byte) error {
+func HandleGuard(tm *task_manager.TaskManager, cmdData []byte) error {
 	var msg response.GuardMessage
 	if err := json.Unmarshal(cmdData, &msg); err != nil {
 		logger.Error(fmt.Sprintf("[GuardHandler] 解析大航海消息失败: %v", err))
@@ -25,13 +32,17 @@ func HandleGuard(cmdData []byte) error {
 		msg.Data.UserInfo.UName, msg.Data.GuardLevel, msg.Data.GuardNum, msg.Data.GuardUnit,
 		msg.Data.Price))
 
+	// 刚开通的大航海等级可能解锁专属音色，也可能低于当前音色的要求
+	command.EnforceVoiceEligibility(tm, msg.Data.UserInfo.UName,
+		command.SupporterOf(msg.Data.UserInfo.OpenID, msg.Data.UserInfo.UName, msg.Data.GuardLevel, msg.Data.FansMedalLevel))
+
 	guardLevels := map[int]string{1: "总督", 2: "提督", 3: "舰长"}
 	guardName := "大航海"
 	if level, exists := guardLevels[msg.Data.GuardLevel]; exists {
 		guardName = level
 	}
 
-	usingLLMReply := config.GetUseLLMReplay()
+	usingLLMReply := tm.UseLLMReply()
 	if usingLLMReply {
 		var eventDescription string
 		if msg.Data.GuardNum > 1 {
@@ -41,7 +52,7 @@ func HandleGuard(cmdData []byte) error {
 			eventDescription = fmt.Sprintf("【大航海】用户 %s 购买了 %s（价值：%d）",
 				msg.Data.UserInfo.UName, guardName, msg.Data.Price)
 		}
-		if err := task_manager.AddText(eventDescription, task_manager.TextTypeNormal, user.GetUserVoice(msg.Data.UserInfo.UName)); err != nil {
+		if err := tm.AddText(eventDescription, task_manager.TextTypeNormal, user.GetUserVoice(msg.Data.UserInfo.UName)); err != nil {
 			logger.Error(fmt.Sprintf("[GuardHandler] 添加事件到任务管理器失败: %v", err))
 		}
 	} else {
@@ -66,8 +77,16 @@ func HandleGuard(cmdData []byte) error {
 			}
 		}
 
-		reply := fmt.Sprintf("感谢%s给主播赠送了%s%s，%s", msg.Data.UserInfo.UName, durationPrefix, guardName, common.RandomBlessing())
-		if err := task_manager.AddText(reply, task_manager.TextTypeNoLLMReply, user.GetUserVoice(msg.Data.UserInfo.UName)); err != nil {
+		reply, err := reply_template.Render(reply_template.EventGuard, GuardEvent{
+			GuardData: msg.Data,
+			GuardName: guardName,
+			Duration:  durationPrefix,
+		})
+		if err != nil {
+			logger.Error(fmt.Sprintf("[GuardHandler] 生成回复失败: %v", err))
+			return nil
+		}
+		if err := tm.AddText(reply, task_manager.TextTypeNoLLMReply, user.GetUserVoice(msg.Data.UserInfo.UName)); err != nil {
 			logger.Error(fmt.Sprintf("[GuardHandler] 添加事件到任务管理器失败: %v", err))
 		}
 	}
@@ -8,14 +8,20 @@ import (
 	"github.com/CoffeeSwt/bilibili-tts-chat/response"
 )
 
-func HandleInteractionEnd(cmdData []byte) error {
+// HandleInteractionEnd 处理消息推送结束消息
+// onInteractionEnd 为消息推送结束时的回调，参数为结束的场次id，用于重新建立场次
+func HandleInteractionEnd(cmdData []byte, onInteractionEnd func(gameID string)) error {
 	var msg response.InteractionEndMessage
 	if err := json.Unmarshal(cmdData, &msg); err != nil {
 		logger.Error(fmt.Sprintf("[InteractionEndHandler] 解析互动结束消息失败: %v", err))
 		return err
 	}
 
-	logger.Info("互动结束")
+	logger.Info(fmt.Sprintf("互动结束，场次: %s", msg.Data.GameID))
+
+	if onInteractionEnd != nil {
+		onInteractionEnd(msg.Data.GameID)
+	}
 
 	return nil
 }
@@ -3,18 +3,26 @@ package like
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/CoffeeSwt/bilibili-tts-chat/config"
-	"github.com/CoffeeSwt/bilibili-tts-chat/handler/common"
 	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/reply_template"
 	"github.com/CoffeeSwt/bilibili-tts-chat/response"
 	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
 	"github.com/CoffeeSwt/bilibili-tts-chat/user"
 )
 
+// LikeEvent 点赞回复模板的数据
+type LikeEvent struct {
+	Who   string // 点赞的观众，如 "A、B、C等5位观众"
+	Total int    // 点赞总数
+	Users int    // 点赞人数
+}
+
 // HandleLike 处理点赞消息
-// 这是点赞消息的核心处理函数，负责解析和处理用户的点赞行为
-func HandleLike(cmdData []byte) error {
+// 这是点赞消息的核心处理函数，负责解析用户的点赞行为，点赞会在窗口结束后合并播报
+func (a *Aggregator) HandleLike(cmdData []byte) error {
 	var msg response.LikeMessage
 	if err := json.Unmarshal(cmdData, &msg); err != nil {
 		logger.Error(fmt.Sprintf("[LikeHandler] 解析点赞消息失败: %v", err))
@@ -24,22 +32,69 @@ func HandleLike(cmdData []byte) error {
 	logger.Info(fmt.Sprintf("[点赞] 用户: %s, 点赞数: %d, 房间: %d",
 		msg.Data.UName, msg.Data.LikeCount, msg.Data.RoomID))
 
-	usingLLMReply := config.GetUseLLMReplay()
+	a.add(msg.Data)
+	return nil
+}
+
+// announce 合并播报一个窗口内的点赞，likers 按点赞数从多到少排列
+func announce(tm *task_manager.TaskManager, likers []*liker) {
+	all := len(likers)
+	likers = qualified(likers, config.GetLikeUserMinCount())
+	if len(likers) == 0 {
+		if all > 0 {
+			logger.Info(fmt.Sprintf("[点赞] 窗口内 %d 人的点赞数都未达到单个用户的播报门槛", all))
+		}
+		return
+	}
+
+	total := 0
+	for _, l := range likers {
+		total += l.count
+	}
+	if total < config.GetLikeMinCount() {
+		logger.Info(fmt.Sprintf("[点赞] 窗口内共 %d 人点了 %d 个赞，未达到播报门槛", len(likers), total))
+		return
+	}
+
+	names := make([]string, 0, maxNamedUsers)
+	for i := 0; i < len(likers) && i < maxNamedUsers; i++ {
+		names = append(names, likers[i].uname)
+	}
+	who := strings.Join(names, "、")
+	if len(likers) > maxNamedUsers {
+		who += fmt.Sprintf("等%d位观众", len(likers))
+	}
+	// 使用点赞最多的用户的音色播报
+	voice := user.GetUserVoice(likers[0].uname)
+
+	usingLLMReply := tm.UseLLMReply()
 	if usingLLMReply {
-		eventDescription := fmt.Sprintf("【点赞】用户 %s 为直播间点了 %d 个赞",
-			msg.Data.UName, msg.Data.LikeCount)
-		if err := task_manager.AddText(eventDescription, task_manager.TextTypeNormal, user.GetUserVoice(msg.Data.UName)); err != nil {
+		eventDescription := fmt.Sprintf("【点赞】用户 %s 为直播间点了 %d 个赞", who, total)
+		if len(likers) > 1 {
+			eventDescription = fmt.Sprintf("【点赞】用户 %s 一共为直播间点了 %d 个赞", who, total)
+		}
+		if err := tm.AddText(eventDescription, task_manager.TextTypeNormal, voice); err != nil {
 			logger.Error(fmt.Sprintf("[LikeHandler] 添加事件到任务管理器失败: %v", err))
 		}
 	} else {
-		reply := fmt.Sprintf("感谢%s的点赞", msg.Data.UName)
-		if msg.Data.LikeCount > 5 {
-			reply = fmt.Sprintf("感谢%s的点赞，%s", msg.Data.UName, common.RandomBlessing())
+		reply, err := reply_template.Render(reply_template.EventLike, LikeEvent{Who: who, Total: total, Users: len(likers)})
+		if err != nil {
+			logger.Error(fmt.Sprintf("[LikeHandler] 生成回复失败: %v", err))
+			return
 		}
-		if err := task_manager.AddText(reply, task_manager.TextTypeNoLLMReply, user.GetUserVoice(msg.Data.UName)); err != nil {
+		if err := tm.AddText(reply, task_manager.TextTypeNoLLMReply, voice); err != nil {
 			logger.Error(fmt.Sprintf("[LikeHandler] 添加事件到任务管理器失败: %v", err))
 		}
 	}
+}
 
-	return nil
+// qualified 返回点赞数达到 min 的用户，保持原来的顺序
+func qualified(likers []*liker, min int) []*liker {
+	var res []*liker
+	for _, l := range likers {
+		if l.count >= min {
+			res = append(res, l)
+		}
+	}
+	return res
 }
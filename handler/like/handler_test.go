@@ -0,0 +1,31 @@
+package like
+
+import "testing"
+
+func TestQualified(t *testing.T) {
+	likers := []*liker{
+		{uname: "A", count: 20},
+		{uname: "B", count: 3},
+		{uname: "C", count: 2},
+		{uname: "D", count: 1},
+	}
+	tests := []struct {
+		min  int
+		want []string
+	}{
+		{1, []string{"A", "B", "C", "D"}},
+		{3, []string{"A", "B"}},
+		{21, nil},
+	}
+	for _, tt := range tests {
+		got := qualified(likers, tt.min)
+		if len(got) != len(tt.want) {
+			t.Fatalf("qualified(min=%d) 返回 %d 人, want %d", tt.min, len(got), len(tt.want))
+		}
+		for i, l := range got {
+			if l.uname != tt.want[i] {
+				t.Errorf("qualified(min=%d)[%d] = %s, want %s", tt.min, i, l.uname, tt.want[i])
+			}
+		}
+	}
+}
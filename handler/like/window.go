@@ -0,0 +1,108 @@
+package like
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/config"
+	"github.com/CoffeeSwt/bilibili-tts-chat/response"
+	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
+)
+
+// maxNamedUsers 合并播报时最多念出的昵称数
+const maxNamedUsers = 3
+
+// liker 窗口内一个用户的点赞
+type liker struct {
+	uname string
+	count int
+	order int // 第一次点赞的顺序，点赞数相同时先点赞的排在前面
+}
+
+// Aggregator 点赞合并器，收到第一个点赞后开始计时，窗口结束时合并播报窗口内所有用户的点赞
+type Aggregator struct {
+	mu      sync.Mutex
+	tm      *task_manager.TaskManager
+	likers  map[string]*liker // 按 open_id 统计
+	timer   *time.Timer
+	stopped bool
+}
+
+// NewAggregator 创建点赞合并器，播报写入 tm
+func NewAggregator(tm *task_manager.TaskManager) *Aggregator {
+	return &Aggregator{
+		tm:     tm,
+		likers: make(map[string]*liker),
+	}
+}
+
+// add 把点赞计入当前窗口，没有进行中的窗口时开启新窗口
+func (a *Aggregator) add(like response.LikeData) {
+	key := like.OpenID
+	if key == "" {
+		key = like.UName
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.stopped {
+		return
+	}
+
+	l, exists := a.likers[key]
+	if !exists {
+		l = &liker{uname: like.UName, order: len(a.likers)}
+		a.likers[key] = l
+	}
+	l.count += like.LikeCount
+
+	if a.timer == nil {
+		a.timer = time.AfterFunc(config.GetLikeWindow(), a.flush)
+	}
+}
+
+// flush 窗口结束，按点赞数从多到少合并播报
+func (a *Aggregator) flush() {
+	a.mu.Lock()
+	if a.stopped {
+		a.mu.Unlock()
+		return
+	}
+	likers := make([]*liker, 0, len(a.likers))
+	for _, l := range a.likers {
+		likers = append(likers, l)
+	}
+	a.likers = make(map[string]*liker)
+	a.timer = nil
+	a.mu.Unlock()
+
+	sort.Slice(likers, func(i, j int) bool {
+		if likers[i].count != likers[j].count {
+			return likers[i].count > likers[j].count
+		}
+		return likers[i].order < likers[j].order
+	})
+	announce(a.tm, likers)
+}
+
+// Pending 返回当前窗口内等待播报的用户数
+func (a *Aggregator) Pending() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.likers)
+}
+
+// Stop 停止合并器，丢弃当前窗口内还没有播报的点赞
+func (a *Aggregator) Stop() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.stopped = true
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+	a.likers = make(map[string]*liker)
+}
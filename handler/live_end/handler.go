@@ -3,14 +3,27 @@ package live_end
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/CoffeeSwt/bilibili-tts-chat/config"
 	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/reply_template"
 	"github.com/CoffeeSwt/bilibili-tts-chat/response"
 	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
 )
 
-func HandleLiveEnd(cmdData []byte) error {
+// maxNamedSupporters 总结播报中点名感谢的观众数
+const maxNamedSupporters = 3
+
+// LiveEndEvent 下播总结回复模板的数据
+type LiveEndEvent struct {
+	Report
+	Supporters string // 送礼最多的观众，如 "A、B、C"
+}
+
+// HandleLiveEnd 处理直播结束消息，播报本场总结并写入报告，然后开始统计下一场
+func (s *Summary) HandleLiveEnd(cmdData []byte) error {
 	var msg response.LiveEndMessage
 	if err := json.Unmarshal(cmdData, &msg); err != nil {
 		logger.Error(fmt.Sprintf("[LiveEndHandler] 解析直播结束消息失败: %v", err))
@@ -20,15 +33,51 @@ func HandleLiveEnd(cmdData []byte) error {
 	logger.Info(fmt.Sprintf("[直播结束] 房间: %d, 结束时间: %d",
 		msg.Data.RoomID, msg.Data.Timestamp))
 
-	usingLLMReply := config.GetUseLLMReplay()
+	s.mu.Lock()
+	report := s.report(msg.Data.RoomID, msg.Data.Title, eventTime(msg.Data.Timestamp))
+	s.reset(time.Time{}, "")
+	s.mu.Unlock()
+
+	logger.Info(fmt.Sprintf("[直播总结] 时长: %s, 弹幕: %d, 观众: %d, 礼物: %s元, 付费留言: %d元, 新增大航海: %d",
+		report.Duration, report.DanmakuCount, report.UniqueViewers, formatYuan(report.GiftValue),
+		report.SuperChatRMB, len(report.NewGuards)))
+	if path, err := writeReport(report); err != nil {
+		logger.Error(fmt.Sprintf("[直播总结] %v", err))
+	} else {
+		logger.Info(fmt.Sprintf("[直播总结] 报告已写入: %s", path))
+	}
+
+	var names []string
+	for _, sup := range report.TopGifters {
+		if len(names) == maxNamedSupporters {
+			break
+		}
+		names = append(names, sup.UName)
+	}
+
+	usingLLMReply := s.tm.UseLLMReply()
 	if usingLLMReply {
-		eventDescription := fmt.Sprintf("【直播结束】主播结束了直播，房间号：%d", msg.Data.RoomID)
-		if err := task_manager.AddText(eventDescription, task_manager.TextTypeNormal, config.GetRandomVoice()); err != nil {
+		eventDescription := fmt.Sprintf("【直播结束】主播结束了直播，本场共收到%d条弹幕，%d位观众来过，礼物总价值%s元",
+			report.DanmakuCount, report.UniqueViewers, formatYuan(report.GiftValue))
+		if len(names) > 0 {
+			eventDescription += fmt.Sprintf("，送礼最多的观众是%s", strings.Join(names, "、"))
+		}
+		if report.BlindBoxes.Count > 0 {
+			eventDescription += fmt.Sprintf("，观众共开了%d个盲盒，%s", report.BlindBoxes.Count, report.BlindBoxes.ProfitText())
+		}
+		if err := s.tm.AddText(eventDescription, task_manager.TextTypeNormal, config.GetRandomVoice()); err != nil {
 			logger.Error(fmt.Sprintf("[LiveEndHandler] 添加事件到任务管理器失败: %v", err))
 		}
 	} else {
-		reply := fmt.Sprintf("直播结束，房间号：%d", msg.Data.RoomID)
-		if err := task_manager.AddText(reply, task_manager.TextTypeNoLLMReply, config.GetRandomVoice()); err != nil {
+		reply, err := reply_template.Render(reply_template.EventLiveEnd, LiveEndEvent{
+			Report:     report,
+			Supporters: strings.Join(names, "、"),
+		})
+		if err != nil {
+			logger.Error(fmt.Sprintf("[LiveEndHandler] 生成回复失败: %v", err))
+			return nil
+		}
+		if err := s.tm.AddText(reply, task_manager.TextTypeNoLLMReply, config.GetRandomVoice()); err != nil {
 			logger.Error(fmt.Sprintf("[LiveEndHandler] 添加事件到任务管理器失败: %v", err))
 		}
 	}
@@ -0,0 +1,182 @@
+package live_end
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/handler/send_gift"
+)
+
+// maxReportGifters 报告中列出的送礼观众数
+const maxReportGifters = 10
+
+// Supporter 送礼观众
+type Supporter struct {
+	UName string `json:"uname"`
+	Value int    `json:"value"` // 礼物总价值，1000 = 1元
+}
+
+// GuardRecord 本场新增的大航海
+type GuardRecord struct {
+	UName string `json:"uname"`
+	Level string `json:"level"` // 总督、提督、舰长
+	Num   int    `json:"num"`
+	Unit  string `json:"unit"`
+}
+
+// Report 直播场次报告
+type Report struct {
+	RoomID         int           `json:"room_id"`
+	Title          string        `json:"title"`
+	StartTime      time.Time     `json:"start_time"`
+	EndTime        time.Time     `json:"end_time"`
+	Duration       string        `json:"duration"`
+	DanmakuCount   int           `json:"danmaku_count"`
+	UniqueViewers  int           `json:"unique_viewers"`
+	GiftValue      int           `json:"gift_value"` // 付费礼物总价值，1000 = 1元
+	TopGifters     []Supporter   `json:"top_gifters"`
+	SuperChatCount int           `json:"super_chat_count"`
+	SuperChatRMB   int           `json:"super_chat_rmb"` // 付费留言总金额（元）
+	NewGuards      []GuardRecord `json:"new_guards"`
+	PeakMinute     time.Time     `json:"peak_minute,omitzero"` // 互动最多的一分钟，没有互动时为空
+	PeakCount      int           `json:"peak_count"`
+	BlindBoxes     BlindBoxes    `json:"blind_boxes"`
+}
+
+// BlindBoxes 本场盲盒盈亏
+type BlindBoxes struct {
+	send_gift.BlindBoxTotal
+	Profit int                       `json:"profit"` // 盈亏，正数为赚，1000 = 1元 = 10电池
+	Users  []send_gift.BlindBoxTotal `json:"users"`  // 每个观众的累计，按盈亏从多到少排序
+}
+
+// report 生成当前场次的报告，调用前需要加锁
+func (s *Summary) report(roomID int, title string, end time.Time) Report {
+	if title == "" {
+		title = s.title
+	}
+	r := Report{
+		RoomID:         roomID,
+		Title:          title,
+		StartTime:      s.startTime,
+		EndTime:        end,
+		Duration:       end.Sub(s.startTime).Round(time.Second).String(),
+		DanmakuCount:   s.danmaku,
+		UniqueViewers:  len(s.viewers),
+		GiftValue:      s.giftValue,
+		TopGifters:     make([]Supporter, 0, len(s.gifters)),
+		SuperChatCount: s.superChats,
+		SuperChatRMB:   s.superChatRMB,
+		NewGuards:      append([]GuardRecord{}, s.guards...),
+	}
+	total := s.blind.Total()
+	r.BlindBoxes = BlindBoxes{BlindBoxTotal: total, Profit: total.Profit(), Users: s.blind.Users()}
+
+	for _, sup := range s.gifters {
+		r.TopGifters = append(r.TopGifters, *sup)
+	}
+	sort.Slice(r.TopGifters, func(i, j int) bool {
+		if r.TopGifters[i].Value != r.TopGifters[j].Value {
+			return r.TopGifters[i].Value > r.TopGifters[j].Value
+		}
+		return r.TopGifters[i].UName < r.TopGifters[j].UName
+	})
+	if len(r.TopGifters) > maxReportGifters {
+		r.TopGifters = r.TopGifters[:maxReportGifters]
+	}
+
+	for minute, count := range s.activity {
+		if count > r.PeakCount || (count == r.PeakCount && minute < r.PeakMinute.Unix()) {
+			r.PeakMinute = time.Unix(minute, 0)
+			r.PeakCount = count
+		}
+	}
+	return r
+}
+
+// Markdown 把报告格式化为Markdown
+func (r Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# 直播总结：%s\n\n", r.Title)
+	fmt.Fprintf(&b, "- 房间号：%d\n", r.RoomID)
+	fmt.Fprintf(&b, "- 时间：%s ~ %s（%s）\n", r.StartTime.Format("2006-01-02 15:04:05"), r.EndTime.Format("2006-01-02 15:04:05"), r.Duration)
+	fmt.Fprintf(&b, "- 弹幕数：%d\n", r.DanmakuCount)
+	fmt.Fprintf(&b, "- 观众数：%d\n", r.UniqueViewers)
+	fmt.Fprintf(&b, "- 礼物总价值：%s元\n", formatYuan(r.GiftValue))
+	fmt.Fprintf(&b, "- 付费留言：%d条，共%d元\n", r.SuperChatCount, r.SuperChatRMB)
+	if r.PeakCount > 0 {
+		fmt.Fprintf(&b, "- 互动高峰：%s，%d次互动\n", r.PeakMinute.Format("15:04"), r.PeakCount)
+	}
+
+	b.WriteString("\n## 送礼榜\n\n")
+	if len(r.TopGifters) == 0 {
+		b.WriteString("本场没有收到付费礼物\n")
+	} else {
+		b.WriteString("| 排名 | 观众 | 礼物价值（元） |\n| :--- | :--- | :--- |\n")
+		for i, sup := range r.TopGifters {
+			fmt.Fprintf(&b, "| %d | %s | %s |\n", i+1, sup.UName, formatYuan(sup.Value))
+		}
+	}
+
+	if r.BlindBoxes.Count > 0 {
+		fmt.Fprintf(&b, "\n## 盲盒\n\n共开出%d个盲盒，花费%s电池，开出%s电池，%s\n\n",
+			r.BlindBoxes.Count, formatBattery(r.BlindBoxes.Cost), formatBattery(r.BlindBoxes.Value), r.BlindBoxes.ProfitText())
+		b.WriteString("| 观众 | 盲盒数 | 花费（电池） | 开出（电池） | 盈亏（电池） |\n| :--- | :--- | :--- | :--- | :--- |\n")
+		for _, t := range r.BlindBoxes.Users {
+			fmt.Fprintf(&b, "| %s | %d | %s | %s | %s |\n",
+				t.UName, t.Count, formatBattery(t.Cost), formatBattery(t.Value), formatBattery(t.Profit()))
+		}
+	}
+
+	b.WriteString("\n## 新增大航海\n\n")
+	if len(r.NewGuards) == 0 {
+		b.WriteString("本场没有新增大航海\n")
+	} else {
+		for _, g := range r.NewGuards {
+			fmt.Fprintf(&b, "- %s：%s %d%s\n", g.UName, g.Level, g.Num, g.Unit)
+		}
+	}
+	return b.String()
+}
+
+// writeReport 把报告写入 logs 目录，同时生成Markdown和JSON两个文件，返回Markdown文件路径
+func writeReport(r Report) (string, error) {
+	workDir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("获取工作目录失败: %v", err)
+	}
+	dir := filepath.Join(workDir, "logs")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("创建日志目录失败: %v", err)
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("live_summary_%d_%s", r.RoomID, r.EndTime.Format("20060102_150405")))
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("序列化直播报告失败: %v", err)
+	}
+	if err := os.WriteFile(base+".json", data, 0644); err != nil {
+		return "", fmt.Errorf("写入直播报告失败: %v", err)
+	}
+	if err := os.WriteFile(base+".md", []byte(r.Markdown()), 0644); err != nil {
+		return "", fmt.Errorf("写入直播报告失败: %v", err)
+	}
+	return base + ".md", nil
+}
+
+// formatBattery 把礼物价值格式化为电池，1000 = 10电池
+func formatBattery(value int) string {
+	return strconv.FormatFloat(float64(value)/100, 'f', -1, 64)
+}
+
+// formatYuan 把礼物价值格式化为元，最多保留两位小数
+func formatYuan(value int) string {
+	return strconv.FormatFloat(math.Round(float64(value)/10)/100, 'f', -1, 64)
+}
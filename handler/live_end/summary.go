@@ -0,0 +1,185 @@
+package live_end
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/handler/send_gift"
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/response"
+	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
+)
+
+// Summary 直播场次统计，从开播到下播统计弹幕、观众、礼物等数据，下播时播报总结并写入报告
+// 没有收到开播消息时（如开播后才启动）从第一条消息开始统计
+type Summary struct {
+	mu    sync.Mutex
+	tm    *task_manager.TaskManager
+	blind *send_gift.BlindBoxLedger // 盲盒盈亏，由礼物处理器记录，每场清空
+
+	title        string
+	startTime    time.Time
+	danmaku      int
+	viewers      map[string]struct{}   // 来过的观众，按open_id区分，没有时按昵称
+	gifters      map[string]*Supporter // 送礼观众，按open_id区分，没有时按昵称
+	giftValue    int                   // 付费礼物总价值，1000 = 1元
+	superChats   int
+	superChatRMB int
+	guards       []GuardRecord
+	activity     map[int64]int // 每分钟的互动数，键为分钟开始的时间戳
+}
+
+// NewSummary 创建直播场次统计，总结播报写入 tm，blind 为礼物处理器的盲盒统计
+func NewSummary(tm *task_manager.TaskManager, blind *send_gift.BlindBoxLedger) *Summary {
+	s := &Summary{tm: tm, blind: blind}
+	s.reset(time.Time{}, "")
+	return s
+}
+
+// reset 开始新的场次，调用前需要加锁
+func (s *Summary) reset(start time.Time, title string) {
+	s.title = title
+	s.startTime = start
+	s.danmaku = 0
+	s.viewers = make(map[string]struct{})
+	s.gifters = make(map[string]*Supporter)
+	s.giftValue = 0
+	s.superChats = 0
+	s.superChatRMB = 0
+	s.guards = nil
+	s.activity = make(map[int64]int)
+	s.blind.Reset()
+}
+
+// Observe 统计一条消息，收到开播消息时重新开始统计
+func (s *Summary) Observe(cmd string, cmdData []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.startTime.IsZero() {
+		s.startTime = time.Now()
+	}
+
+	switch cmd {
+	case "LIVE_OPEN_PLATFORM_LIVE_START":
+		var msg response.LiveStartMessage
+		if s.decode(cmdData, &msg) {
+			s.reset(eventTime(msg.Data.Timestamp), msg.Data.Title)
+			logger.Info(fmt.Sprintf("[直播总结] 开始统计本场直播: %s", msg.Data.Title))
+		}
+	case "LIVE_OPEN_PLATFORM_DM":
+		var msg response.DanmakuMessage
+		if s.decode(cmdData, &msg) {
+			s.danmaku++
+			s.viewer(msg.Data.OpenID, msg.Data.UName)
+			s.active(msg.Data.Timestamp)
+		}
+	case "LIVE_OPEN_PLATFORM_SEND_GIFT":
+		var msg response.GiftMessage
+		if s.decode(cmdData, &msg) {
+			s.viewer(msg.Data.OpenID, msg.Data.UName)
+			s.active(msg.Data.Timestamp)
+			if msg.Data.Paid {
+				value := msg.Data.Price * msg.Data.GiftNum
+				s.giftValue += value
+				s.supporter(msg.Data.OpenID, msg.Data.UName).Value += value
+			}
+		}
+	case "LIVE_OPEN_PLATFORM_SUPER_CHAT":
+		var msg response.SuperChatMessage
+		if s.decode(cmdData, &msg) {
+			s.viewer(msg.Data.OpenID, msg.Data.UName)
+			s.active(msg.Data.Timestamp)
+			s.superChats++
+			s.superChatRMB += msg.Data.RMB
+		}
+	case "LIVE_OPEN_PLATFORM_GUARD":
+		var msg response.GuardMessage
+		if s.decode(cmdData, &msg) {
+			s.viewer(msg.Data.UserInfo.OpenID, msg.Data.UserInfo.UName)
+			s.active(msg.Data.Timestamp)
+			s.guards = append(s.guards, GuardRecord{
+				UName: msg.Data.UserInfo.UName,
+				Level: guardName(msg.Data.GuardLevel),
+				Num:   msg.Data.GuardNum,
+				Unit:  msg.Data.GuardUnit,
+			})
+		}
+	case "LIVE_OPEN_PLATFORM_LIKE":
+		var msg response.LikeMessage
+		if s.decode(cmdData, &msg) {
+			s.viewer(msg.Data.OpenID, msg.Data.UName)
+			s.active(msg.Data.Timestamp)
+		}
+	case "LIVE_OPEN_PLATFORM_LIVE_ROOM_ENTER":
+		var msg response.RoomEnterMessage
+		if s.decode(cmdData, &msg) {
+			s.viewer(msg.Data.OpenID, msg.Data.UName)
+		}
+	}
+}
+
+// decode 解析消息，失败时只记录日志，由对应的处理函数报告错误
+func (s *Summary) decode(cmdData []byte, v any) bool {
+	if err := json.Unmarshal(cmdData, v); err != nil {
+		logger.Warn(fmt.Sprintf("[直播总结] 解析消息失败: %v", err))
+		return false
+	}
+	return true
+}
+
+// viewer 记录来过的观众
+func (s *Summary) viewer(openID, uname string) {
+	if key := userKey(openID, uname); key != "" {
+		s.viewers[key] = struct{}{}
+	}
+}
+
+// supporter 获取送礼观众的统计
+func (s *Summary) supporter(openID, uname string) *Supporter {
+	key := userKey(openID, uname)
+	sup, exists := s.gifters[key]
+	if !exists {
+		sup = &Supporter{UName: uname}
+		s.gifters[key] = sup
+	}
+	return sup
+}
+
+// active 记录一次互动，统计每分钟的互动数
+func (s *Summary) active(timestamp int64) {
+	minute := eventTime(timestamp).Truncate(time.Minute).Unix()
+	s.activity[minute]++
+}
+
+// userKey 观众的唯一标识，open_id 为空时使用昵称
+func userKey(openID, uname string) string {
+	if openID != "" {
+		return openID
+	}
+	return uname
+}
+
+// eventTime 消息中的秒级时间戳，没有时使用当前时间
+func eventTime(timestamp int64) time.Time {
+	if timestamp > 0 {
+		return time.Unix(timestamp, 0)
+	}
+	return time.Now()
+}
+
+// guardName 大航海等级名称
+func guardName(level int) string {
+	switch level {
+	case 1:
+		return "总督"
+	case 2:
+		return "提督"
+	case 3:
+		return "舰长"
+	default:
+		return "大航海"
+	}
+}
@@ -9,40 +9,82 @@ import (
 	"github.com/CoffeeSwt/bilibili-tts-chat/config"
 	"github.com/CoffeeSwt/bilibili-tts-chat/intro_promot"
 	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/reply_template"
 	"github.com/CoffeeSwt/bilibili-tts-chat/response"
 	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
 	"github.com/CoffeeSwt/bilibili-tts-chat/user"
 )
 
+// EnterEvent 进入直播间回复模板的数据
+type EnterEvent struct {
+	response.RoomEnterData
+	FirstVisit bool   // 第一次来的观众
+	Returning  bool   // 好久没来的老观众
+	AbsentDays int    // 距上次来的天数，第一次来为-1
+	Promot     string // 引导词，可能为空
+}
+
 // HandleRoomEnter 处理用户进入房间消息
-// 当有用户进入直播间时触发，可以用于欢迎消息、统计等
-func HandleRoomEnter(cmdData []byte) error {
+// 当有用户进入直播间时触发，区分第一次来的观众和好久不见的老观众，刷新页面等短时间内重复进入不会重复欢迎
+func (w *Welcomer) HandleRoomEnter(cmdData []byte) error {
 	var msg response.RoomEnterMessage
 	if err := json.Unmarshal(cmdData, &msg); err != nil {
 		logger.Error(fmt.Sprintf("[RoomHandler] 解析进入房间消息失败: %v", err))
 		return err
 	}
 
+	// GetUserVoice 会刷新活跃时间，需要先取出上次活跃的时间
+	lastActive, known := user.GetLastActiveTime(msg.Data.UName)
 	voice := user.GetUserVoice(msg.Data.UName)
 	logger.Info(fmt.Sprintf("[进入房间][%s][%s] (OpenID: %s)",
 		msg.Data.UName, voice.Name, msg.Data.OpenID))
 
-	usingLLMReply := config.GetUseLLMReplay()
+	userKey := msg.Data.OpenID
+	if userKey == "" {
+		userKey = msg.Data.UName
+	}
+	if ok, reason := w.allow(userKey); !ok {
+		logger.Info(fmt.Sprintf("[进入房间] 不欢迎 %s: %s", msg.Data.UName, reason))
+		return nil
+	}
+
+	// 距上次活跃的天数，第一次来的观众为-1
+	absentDays := -1
+	if known {
+		absentDays = int(time.Since(lastActive).Hours() / 24)
+	}
+	returning := absentDays >= config.GetWelcomeBackDays()
+
+	usingLLMReply := w.tm.UseLLMReply()
 	if usingLLMReply {
 		eventDescription := fmt.Sprintf("【进入房间】用户 %s 进入了直播间", msg.Data.UName)
-		if err := task_manager.AddText(eventDescription, task_manager.TextTypeNormal, voice); err != nil {
+		if !known {
+			eventDescription = fmt.Sprintf("【进入房间】新观众 %s 第一次进入了直播间", msg.Data.UName)
+		} else if returning {
+			eventDescription = fmt.Sprintf("【进入房间】老观众 %s 时隔%d天回到了直播间", msg.Data.UName, absentDays)
+		}
+		if err := w.tm.AddText(eventDescription, task_manager.TextTypeNormal, voice); err != nil {
 			logger.Error(fmt.Sprintf("[RoomHandler] 添加事件到任务管理器失败: %v", err))
 		}
 	} else {
-		// 80% 概率触发引导词
-		rand.Seed(time.Now().UnixNano())
+		// 80% 概率触发引导词，第一次来的观众一定介绍
 		enterPromot := ""
-		if rand.Float64() < 0.8 {
-			enterPromot = "，" + intro_promot.GetEnterPromot()
+		if !known || rand.Float64() < 0.8 {
+			enterPromot = intro_promot.GetEnterPromot()
 		}
 
-		reply := fmt.Sprintf("欢迎%s进入直播间%s", msg.Data.UName, enterPromot)
-		if err := task_manager.AddText(reply, task_manager.TextTypeNoLLMReply, voice); err != nil {
+		reply, err := reply_template.Render(reply_template.EventEnter, EnterEvent{
+			RoomEnterData: msg.Data,
+			FirstVisit:    !known,
+			Returning:     returning,
+			AbsentDays:    absentDays,
+			Promot:        enterPromot,
+		})
+		if err != nil {
+			logger.Error(fmt.Sprintf("[RoomHandler] 生成回复失败: %v", err))
+			return nil
+		}
+		if err := w.tm.AddText(reply, task_manager.TextTypeNoLLMReply, voice); err != nil {
 			logger.Error(fmt.Sprintf("[RoomHandler] 添加事件到任务管理器失败: %v", err))
 		}
 	}
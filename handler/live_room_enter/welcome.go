@@ -0,0 +1,72 @@
+package live_room_enter
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/config"
+	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
+)
+
+// maxWelcomeRecords 记录的欢迎次数超过该值时清理已过冷却时间的用户
+const maxWelcomeRecords = 1000
+
+// Welcomer 进房欢迎，同一用户在冷却时间内只欢迎一次，并限制每分钟的欢迎次数
+type Welcomer struct {
+	mu         sync.Mutex
+	tm         *task_manager.TaskManager
+	lastByUser map[string]time.Time // 用户上次被欢迎的时间
+	recent     []time.Time          // 最近一分钟内的欢迎时间
+	suppressed atomic.Int64         // 因冷却或限流未欢迎的次数
+}
+
+// NewWelcomer 创建进房欢迎，播报写入 tm
+func NewWelcomer(tm *task_manager.TaskManager) *Welcomer {
+	return &Welcomer{
+		tm:         tm,
+		lastByUser: make(map[string]time.Time),
+	}
+}
+
+// Suppressed 返回因冷却或限流未欢迎的次数
+func (w *Welcomer) Suppressed() int64 {
+	return w.suppressed.Load()
+}
+
+// allow 判断是否欢迎该用户，允许时记录本次欢迎
+func (w *Welcomer) allow(userKey string) (bool, string) {
+	now := time.Now()
+	cooldown := config.GetWelcomeCooldown()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if last, ok := w.lastByUser[userKey]; ok && now.Sub(last) < cooldown {
+		w.suppressed.Add(1)
+		return false, "冷却中"
+	}
+
+	// 只保留最近一分钟的欢迎记录
+	cutoff := now.Add(-time.Minute)
+	i := 0
+	for i < len(w.recent) && w.recent[i].Before(cutoff) {
+		i++
+	}
+	w.recent = w.recent[i:]
+	if len(w.recent) >= config.GetWelcomeMaxPerMinute() {
+		w.suppressed.Add(1)
+		return false, "超过每分钟欢迎上限"
+	}
+
+	w.recent = append(w.recent, now)
+	w.lastByUser[userKey] = now
+	if len(w.lastByUser) > maxWelcomeRecords {
+		for key, last := range w.lastByUser {
+			if now.Sub(last) >= cooldown {
+				delete(w.lastByUser, key)
+			}
+		}
+	}
+	return true, ""
+}
@@ -6,11 +6,12 @@ import (
 
 	"github.com/CoffeeSwt/bilibili-tts-chat/config"
 	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/reply_template"
 	"github.com/CoffeeSwt/bilibili-tts-chat/response"
 	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
 )
 
-func HandleLiveStart(cmdData []byte) error {
+func HandleLiveStart(tm *task_manager.TaskManager, cmdData []byte) error {
 	var msg response.LiveStartMessage
 	if err := json.Unmarshal(cmdData, &msg); err != nil {
 		logger.Error(fmt.Sprintf("[LiveStartHandler] 解析直播开始消息失败: %v", err))
@@ -20,15 +21,19 @@ func HandleLiveStart(cmdData []byte) error {
 	logger.Info(fmt.Sprintf("[直播开始] 房间: %d, 开始时间: %d",
 		msg.Data.RoomID, msg.Data.Timestamp))
 
-	usingLLMReply := config.GetUseLLMReplay()
+	usingLLMReply := tm.UseLLMReply()
 	if usingLLMReply {
 		eventDescription := fmt.Sprintf("【直播开始】主播开始了直播，房间号：%d", msg.Data.RoomID)
-		if err := task_manager.AddText(eventDescription, task_manager.TextTypeNormal, config.GetRandomVoice()); err != nil {
+		if err := tm.AddText(eventDescription, task_manager.TextTypeNormal, config.GetRandomVoice()); err != nil {
 			logger.Error(fmt.Sprintf("[LiveStartHandler] 添加事件到任务管理器失败: %v", err))
 		}
 	} else {
-		reply := fmt.Sprintf("直播开始，房间号：%d", msg.Data.RoomID)
-		if err := task_manager.AddText(reply, task_manager.TextTypeNoLLMReply, config.GetRandomVoice()); err != nil {
+		reply, err := reply_template.Render(reply_template.EventLiveStart, msg.Data)
+		if err != nil {
+			logger.Error(fmt.Sprintf("[LiveStartHandler] 生成回复失败: %v", err))
+			return nil
+		}
+		if err := tm.AddText(reply, task_manager.TextTypeNoLLMReply, config.GetRandomVoice()); err != nil {
 			logger.Error(fmt.Sprintf("[LiveStartHandler] 添加事件到任务管理器失败: %v", err))
 		}
 	}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/dedup"
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+)
+
+// Logging 记录收到的消息类型
+func Logging() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(msg *Message) error {
+			logger.Info(fmt.Sprintf("收到消息类型: %s", msg.Cmd))
+			return next(msg)
+		}
+	}
+}
+
+// Dedup 按msg_id去重，同一条消息可能因重连或多包帧重复到达，只处理一次
+func Dedup(store *dedup.Store) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(msg *Message) error {
+			if msg.MsgID != "" && store.Seen(msg.Cmd+":"+msg.MsgID) {
+				logger.Info(fmt.Sprintf("忽略重复消息: %s, msg_id: %s", msg.Cmd, msg.MsgID))
+				return nil
+			}
+			return next(msg)
+		}
+	}
+}
+
+// Filter 过滤消息，allow 返回false时丢弃消息
+func Filter(allow func(msg *Message) bool) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(msg *Message) error {
+			if !allow(msg) {
+				logger.Debug(fmt.Sprintf("消息被过滤: %s", msg.Cmd))
+				return nil
+			}
+			return next(msg)
+		}
+	}
+}
+
+// Metrics 按消息类型统计处理数和失败数
+type Metrics struct {
+	mu     sync.Mutex
+	counts map[string]int
+	failed map[string]int
+}
+
+// NewMetrics 创建消息统计
+func NewMetrics() *Metrics {
+	return &Metrics{
+		counts: make(map[string]int),
+		failed: make(map[string]int),
+	}
+}
+
+// Middleware 返回统计中间件
+func (m *Metrics) Middleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(msg *Message) error {
+			err := next(msg)
+			m.mu.Lock()
+			m.counts[msg.Cmd]++
+			if err != nil {
+				m.failed[msg.Cmd]++
+			}
+			m.mu.Unlock()
+			return err
+		}
+	}
+}
+
+// Counts 返回每种消息的处理数和失败数
+func (m *Metrics) Counts() (counts, failed map[string]int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counts = make(map[string]int, len(m.counts))
+	for cmd, n := range m.counts {
+		counts[cmd] = n
+	}
+	failed = make(map[string]int, len(m.failed))
+	for cmd, n := range m.failed {
+		failed[cmd] = n
+	}
+	return counts, failed
+}
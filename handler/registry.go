@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// Message 分发中的消息
+type Message struct {
+	Cmd   string // 消息类型
+	MsgID string // 消息唯一id，没有时为空
+	Data  []byte // 原始消息
+}
+
+// HandlerFunc 消息处理函数
+type HandlerFunc func(msg *Message) error
+
+// Middleware 包装消息分发，可以在处理前后记录日志、统计，或者不调用 next 直接丢弃消息
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Registry 按消息类型注册的处理函数，同一类型可以注册多个处理函数
+// 中间件按添加顺序从外到内包装整个分发过程，没有处理函数的消息交给兜底处理
+// 可以在运行时注册，新的平台消息或替换已有的处理不需要修改分发代码
+type Registry struct {
+	mu         sync.RWMutex
+	handlers   map[string][]HandlerFunc
+	middleware []Middleware
+	fallback   HandlerFunc
+}
+
+// NewRegistry 创建消息处理注册表，fallback 为未知消息的兜底处理，为空时忽略未知消息
+func NewRegistry(fallback HandlerFunc) *Registry {
+	return &Registry{
+		handlers: make(map[string][]HandlerFunc),
+		fallback: fallback,
+	}
+}
+
+// Register 为消息类型添加处理函数，已有的处理函数保留，按注册顺序依次执行
+func (r *Registry) Register(cmd string, h HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[cmd] = append(r.handlers[cmd], h)
+}
+
+// Replace 替换消息类型的所有处理函数
+func (r *Registry) Replace(cmd string, h HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[cmd] = []HandlerFunc{h}
+}
+
+// Unregister 移除消息类型的所有处理函数，之后该类型的消息交给兜底处理
+func (r *Registry) Unregister(cmd string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.handlers, cmd)
+}
+
+// Use 添加中间件，先添加的在外层
+func (r *Registry) Use(mw ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middleware = append(r.middleware, mw...)
+}
+
+// SetFallback 设置未知消息的兜底处理，为空时忽略未知消息
+func (r *Registry) SetFallback(h HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fallback = h
+}
+
+// Commands 返回已注册处理函数的消息类型
+func (r *Registry) Commands() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cmds := make([]string, 0, len(r.handlers))
+	for cmd := range r.handlers {
+		cmds = append(cmds, cmd)
+	}
+	sort.Strings(cmds)
+	return cmds
+}
+
+// Dispatch 经过中间件把消息交给对应的处理函数，多个处理函数的错误会合并返回
+func (r *Registry) Dispatch(msg *Message) error {
+	r.mu.RLock()
+	handlers := append([]HandlerFunc(nil), r.handlers[msg.Cmd]...)
+	middleware := append([]Middleware(nil), r.middleware...)
+	fallback := r.fallback
+	r.mu.RUnlock()
+
+	next := func(msg *Message) error {
+		if len(handlers) == 0 {
+			if fallback == nil {
+				return nil
+			}
+			return fallback(msg)
+		}
+		var errs []error
+		for _, h := range handlers {
+			if err := h(msg); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		next = middleware[i](next)
+	}
+	return next(msg)
+}
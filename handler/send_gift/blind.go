@@ -0,0 +1,247 @@
+package send_gift
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/config"
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/reply_template"
+	"github.com/CoffeeSwt/bilibili-tts-chat/response"
+	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
+	"github.com/CoffeeSwt/bilibili-tts-chat/user"
+)
+
+// BlindBoxTotal 盲盒累计，只有知道盲盒价格的盲盒计入花费和开出价值
+type BlindBoxTotal struct {
+	UName string `json:"uname,omitempty"`
+	Count int    `json:"count"` // 开出的盲盒数
+	Cost  int    `json:"cost"`  // 盲盒花费，1000 = 1元 = 10电池
+	Value int    `json:"value"` // 开出礼物的价值，1000 = 1元 = 10电池
+}
+
+// Profit 盲盒盈亏，正数为赚，1000 = 1元 = 10电池
+func (t BlindBoxTotal) Profit() int {
+	return t.Value - t.Cost
+}
+
+// ProfitText 盈亏描述，如 "赚了10电池"
+func (t BlindBoxTotal) ProfitText() string {
+	return profitText(t.Profit())
+}
+
+// BlindBoxLedger 按场次统计盲盒盈亏，包括每个观众和整场的累计
+type BlindBoxLedger struct {
+	mu    sync.Mutex
+	users map[string]*BlindBoxTotal
+	total BlindBoxTotal
+}
+
+// NewBlindBoxLedger 创建盲盒统计
+func NewBlindBoxLedger() *BlindBoxLedger {
+	return &BlindBoxLedger{users: make(map[string]*BlindBoxTotal)}
+}
+
+// add 记录一条盲盒礼物消息，返回该观众本场的累计
+func (l *BlindBoxLedger) add(gift response.GiftData) BlindBoxTotal {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := userKey(gift)
+	t, exists := l.users[key]
+	if !exists {
+		t = &BlindBoxTotal{UName: gift.UName}
+		l.users[key] = t
+	}
+	t.Count += gift.GiftNum
+	l.total.Count += gift.GiftNum
+	if price := blindBoxPrice(gift); price > 0 {
+		t.Cost += price * gift.GiftNum
+		t.Value += gift.Price * gift.GiftNum
+		l.total.Cost += price * gift.GiftNum
+		l.total.Value += gift.Price * gift.GiftNum
+	}
+	return *t
+}
+
+// User 返回观众本场的盲盒累计
+func (l *BlindBoxLedger) User(gift response.GiftData) BlindBoxTotal {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if t, exists := l.users[userKey(gift)]; exists {
+		return *t
+	}
+	return BlindBoxTotal{UName: gift.UName}
+}
+
+// Total 返回本场的盲盒累计
+func (l *BlindBoxLedger) Total() BlindBoxTotal {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.total
+}
+
+// Users 返回每个观众的盲盒累计，按盈亏从多到少排序
+func (l *BlindBoxLedger) Users() []BlindBoxTotal {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	users := make([]BlindBoxTotal, 0, len(l.users))
+	for _, t := range l.users {
+		users = append(users, *t)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Profit() != users[j].Profit() {
+			return users[i].Profit() > users[j].Profit()
+		}
+		return users[i].UName < users[j].UName
+	})
+	return users
+}
+
+// Reset 清空统计，开始新的场次
+func (l *BlindBoxLedger) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.users = make(map[string]*BlindBoxTotal)
+	l.total = BlindBoxTotal{}
+}
+
+// BlindBoxEvent 盲盒回复模板的数据
+type BlindBoxEvent struct {
+	response.GiftData
+	Box        string // 盲盒名
+	Count      int    // 盲盒数
+	Items      string // 开出的礼物，如 "小花花 x2、小心心"
+	Priced     bool   // 是否知道盲盒价格，不知道时无法计算盈亏
+	Profit     int    // 盈亏，1000 = 10电池
+	ProfitText string // 盈亏描述，如 "赚了10电池"
+}
+
+// blindOpening 一组盲盒开出的礼物
+type blindOpening struct {
+	order  []string       // 开出的礼物名，按第一次开出的顺序
+	counts map[string]int // 每种礼物的数量
+	cost   int            // 盲盒花费，盲盒没有价格时为0
+	value  int            // 开出礼物的价值，只统计有价格的盲盒
+	priced bool           // 是否知道盲盒价格
+}
+
+// add 记录一条盲盒礼物消息
+func (b *blindOpening) add(gift response.GiftData) {
+	if b.counts == nil {
+		b.counts = make(map[string]int)
+	}
+	if _, exists := b.counts[gift.GiftName]; !exists {
+		b.order = append(b.order, gift.GiftName)
+	}
+	b.counts[gift.GiftName] += gift.GiftNum
+	if price := blindBoxPrice(gift); price > 0 {
+		b.priced = true
+		b.cost += price * gift.GiftNum
+		b.value += gift.Price * gift.GiftNum
+	}
+}
+
+// items 开出的礼物，如 "小花花 x2、小心心"
+func (b *blindOpening) items() string {
+	parts := make([]string, 0, len(b.order))
+	for _, name := range b.order {
+		if b.counts[name] > 1 {
+			parts = append(parts, fmt.Sprintf("%s x%d", name, b.counts[name]))
+		} else {
+			parts = append(parts, name)
+		}
+	}
+	return strings.Join(parts, "、")
+}
+
+// announceBlind 播报一组盲盒，gift 为第一条盲盒消息，count 为盲盒总数，total 为该观众本场的累计
+func announceBlind(tm *task_manager.TaskManager, gift response.GiftData, count int, b *blindOpening, total BlindBoxTotal) {
+	box := blindBoxName(gift)
+
+	usingLLMReply := tm.UseLLMReply()
+	if usingLLMReply {
+		eventDescription := fmt.Sprintf("【盲盒】用户 %s 开了 %d个 %s，开出了 %s", gift.UName, count, box, b.items())
+		if b.priced {
+			eventDescription += fmt.Sprintf("（开出价值：%d，盲盒价值：%d，%s）", b.value, b.cost, profitText(b.value-b.cost))
+		}
+		if total.Cost > 0 {
+			eventDescription += fmt.Sprintf("；本场该用户累计开了%d个盲盒，累计%s", total.Count, total.ProfitText())
+		}
+		if err := tm.AddText(eventDescription, task_manager.TextTypeNormal, user.GetUserVoice(gift.UName)); err != nil {
+			logger.Error(fmt.Sprintf("[GiftHandler] 添加事件到任务管理器失败: %v", err))
+		}
+	} else {
+		reply, err := reply_template.Render(reply_template.EventBlindBox, BlindBoxEvent{
+			GiftData:   gift,
+			Box:        box,
+			Count:      count,
+			Items:      b.items(),
+			Priced:     b.priced,
+			Profit:     b.value - b.cost,
+			ProfitText: profitText(b.value - b.cost),
+		})
+		if err != nil {
+			logger.Error(fmt.Sprintf("[GiftHandler] 生成回复失败: %v", err))
+			return
+		}
+		if err := tm.AddText(reply, task_manager.TextTypeNoLLMReply, user.GetUserVoice(gift.UName)); err != nil {
+			logger.Error(fmt.Sprintf("[GiftHandler] 添加事件到任务管理器失败: %v", err))
+		}
+	}
+}
+
+// blindBoxName 盲盒名，消息中没有时使用 "盲盒"
+func blindBoxName(gift response.GiftData) string {
+	if gift.BlindGift.OriginalGiftName != "" {
+		return gift.BlindGift.OriginalGiftName
+	}
+	return "盲盒"
+}
+
+// unpricedBoxes 没有单价的盲盒id，每种盲盒只提示一次
+var unpricedBoxes sync.Map
+
+// blindBoxPrice 盲盒单价，消息中没有时从 user.json 的 blind_box_prices 中查找，都没有时返回0
+func blindBoxPrice(gift response.GiftData) int {
+	if gift.BlindGift.OriginalGiftPrice > 0 {
+		return gift.BlindGift.OriginalGiftPrice
+	}
+	if price, ok := config.GetBlindBoxPrice(gift.BlindGift.BlindGiftID); ok {
+		return price
+	}
+	if _, logged := unpricedBoxes.LoadOrStore(gift.BlindGift.BlindGiftID, true); !logged {
+		logger.Warn(fmt.Sprintf("[GiftHandler] 盲盒 %s（blind_gift_id: %d）没有单价，无法计算盈亏，可以在 user.json 的 blind_box_prices 中设置",
+			blindBoxName(gift), gift.BlindGift.BlindGiftID))
+	}
+	return 0
+}
+
+// profitText 盈亏描述，如 "赚了10电池"、"亏了5电池"
+func profitText(profit int) string {
+	battery := func(v int) string {
+		return strconv.FormatFloat(float64(v)/100, 'f', -1, 64)
+	}
+	switch {
+	case profit > 0:
+		return fmt.Sprintf("赚了%s电池", battery(profit))
+	case profit < 0:
+		return fmt.Sprintf("亏了%s电池", battery(-profit))
+	default:
+		return "不亏不赚"
+	}
+}
+
+// userKey 观众的唯一标识，open_id 为空时使用昵称
+func userKey(gift response.GiftData) string {
+	if gift.OpenID != "" {
+		return gift.OpenID
+	}
+	return gift.UName
+}
@@ -0,0 +1,55 @@
+package send_gift
+
+import (
+	"testing"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/config"
+	"github.com/CoffeeSwt/bilibili-tts-chat/response"
+)
+
+// blindGift 一条盲盒礼物消息
+func blindGift(openID string, boxID, boxPrice, price, num int) response.GiftData {
+	var g response.GiftData
+	g.OpenID, g.UName = openID, openID
+	g.GiftName, g.Price, g.GiftNum = "礼物", price, num
+	g.BlindGift = response.BlindGift{BlindGiftID: boxID, Status: true, OriginalGiftName: "心动盲盒", OriginalGiftPrice: boxPrice}
+	return g
+}
+
+func TestBlindBoxLedgerPrice(t *testing.T) {
+	cfg := config.GetUserConfig()
+	saved := cfg.BlindBoxPrices
+	cfg.BlindBoxPrices = map[string]int{"32251": 15000}
+	t.Cleanup(func() { cfg.BlindBoxPrices = saved })
+
+	l := NewBlindBoxLedger()
+	l.add(blindGift("a", 32251, 15000, 20000, 1)) // 消息中有单价
+	l.add(blindGift("a", 32251, 0, 10000, 2))     // 按单价表
+	l.add(blindGift("b", 99999, 0, 50000, 1))     // 没有单价，不计入盈亏
+
+	a := l.User(blindGift("a", 0, 0, 0, 0))
+	if a.Count != 3 || a.Cost != 45000 || a.Value != 40000 {
+		t.Errorf("a = %+v, want Count 3 Cost 45000 Value 40000", a)
+	}
+	if a.Profit() != -5000 {
+		t.Errorf("a.Profit() = %d, want -5000", a.Profit())
+	}
+	b := l.User(blindGift("b", 0, 0, 0, 0))
+	if b.Count != 1 || b.Cost != 0 || b.Value != 0 {
+		t.Errorf("b = %+v, want Count 1 Cost 0 Value 0", b)
+	}
+	if total := l.Total(); total.Count != 4 || total.Cost != 45000 || total.Value != 40000 {
+		t.Errorf("Total = %+v, want Count 4 Cost 45000 Value 40000", total)
+	}
+
+	var opening blindOpening
+	opening.add(blindGift("b", 99999, 0, 50000, 1))
+	if opening.priced {
+		t.Error("没有单价的盲盒不应计算盈亏")
+	}
+	opening = blindOpening{}
+	opening.add(blindGift("a", 32251, 0, 10000, 1))
+	if !opening.priced || opening.cost != 15000 {
+		t.Errorf("按单价表 priced = %v cost = %d, want true 15000", opening.priced, opening.cost)
+	}
+}
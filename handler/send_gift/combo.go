@@ -0,0 +1,148 @@
+package send_gift
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/config"
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/response"
+	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
+)
+
+// maxComboDuration 一组连击最长的合并时间，超过后即使连击没有结束也会播报
+const maxComboDuration = time.Minute
+
+// comboGroup 正在合并的一组礼物
+type comboGroup struct {
+	first    response.GiftData // 第一条礼物消息，用于获取用户和礼物信息
+	count    int               // 礼物总数
+	value    int               // 总价值，1000 = 1元
+	hits     int               // 收到的礼物消息数
+	started  time.Time
+	timer    *time.Timer
+	deadline time.Time     // 最晚播报时间
+	blind    *blindOpening // 盲盒开出的礼物，不是盲盒时为空
+}
+
+// Aggregator 礼物连击合并器，同一连击的礼物在连击结束后只播报一次
+// 有 combo_id 时按 combo_id 分组，否则按用户和礼物id分组，盲盒按用户和盲盒id分组
+type Aggregator struct {
+	mu      sync.Mutex
+	tm      *task_manager.TaskManager
+	groups  map[string]*comboGroup
+	blind   *BlindBoxLedger
+	stopped bool
+}
+
+// NewAggregator 创建礼物连击合并器，播报写入 tm
+func NewAggregator(tm *task_manager.TaskManager) *Aggregator {
+	return &Aggregator{
+		tm:     tm,
+		groups: make(map[string]*comboGroup),
+		blind:  NewBlindBoxLedger(),
+	}
+}
+
+// BlindBoxes 返回本场的盲盒统计
+func (a *Aggregator) BlindBoxes() *BlindBoxLedger {
+	return a.blind
+}
+
+// add 把礼物加入所属的分组，并重新计算连击结束时间
+func (a *Aggregator) add(gift response.GiftData) {
+	key := comboKey(gift)
+	timeout := comboTimeout(gift)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.stopped {
+		return
+	}
+
+	g, exists := a.groups[key]
+	if !exists {
+		now := time.Now()
+		g = &comboGroup{first: gift, started: now, deadline: now.Add(maxComboDuration)}
+		if gift.BlindGift.Status {
+			g.blind = &blindOpening{}
+		}
+		a.groups[key] = g
+		g.timer = time.AfterFunc(timeout, func() { a.flush(key, g) })
+	} else {
+		// 连击继续，等待时间从最后一次送礼重新计算，但不超过最晚播报时间
+		g.timer.Reset(min(timeout, time.Until(g.deadline)))
+	}
+	g.count += gift.GiftNum
+	g.value += gift.Price * gift.GiftNum
+	g.hits++
+	if g.blind != nil {
+		g.blind.add(gift)
+		a.blind.add(gift)
+	}
+}
+
+// flush 连击结束，播报整组礼物
+func (a *Aggregator) flush(key string, g *comboGroup) {
+	a.mu.Lock()
+	if a.stopped || a.groups[key] != g {
+		a.mu.Unlock()
+		return
+	}
+	delete(a.groups, key)
+	gift := g.first
+	gift.GiftNum = g.count
+	hits, value, blind := g.hits, g.value, g.blind
+	a.mu.Unlock()
+
+	if hits > 1 {
+		logger.Info(fmt.Sprintf("[礼物] 连击结束 用户: %s, 礼物: %s x%d, 共 %d 次连击, 总价值: %d, 用时: %v",
+			gift.UName, gift.GiftName, gift.GiftNum, hits, value, time.Since(g.started).Round(time.Second)))
+	}
+	if blind != nil {
+		announceBlind(a.tm, gift, gift.GiftNum, blind, a.blind.User(gift))
+		return
+	}
+	announce(a.tm, gift, value)
+}
+
+// Pending 返回等待播报的分组数
+func (a *Aggregator) Pending() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.groups)
+}
+
+// Stop 停止合并器，丢弃还没有播报的礼物
+func (a *Aggregator) Stop() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.stopped = true
+	for key, g := range a.groups {
+		g.timer.Stop()
+		delete(a.groups, key)
+	}
+}
+
+// comboKey 礼物分组的键
+func comboKey(gift response.GiftData) string {
+	if gift.BlindGift.Status {
+		// 同一盲盒每次开出的礼物不同，按盲盒合并
+		return fmt.Sprintf("blind:%s:%d", userKey(gift), gift.BlindGift.BlindGiftID)
+	}
+	if gift.ComboInfo.ComboID != "" {
+		return "combo:" + gift.ComboInfo.ComboID
+	}
+	return fmt.Sprintf("user:%s:%d", userKey(gift), gift.GiftID)
+}
+
+// comboTimeout 连击等待时间，消息没有带连击有效期时使用配置
+func comboTimeout(gift response.GiftData) time.Duration {
+	if gift.ComboGift && gift.ComboInfo.ComboTimeout > 0 {
+		return time.Duration(gift.ComboInfo.ComboTimeout) * time.Second
+	}
+	return config.GetGiftComboTimeout()
+}
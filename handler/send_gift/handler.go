@@ -4,50 +4,75 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"github.com/CoffeeSwt/bilibili-tts-chat/config"
-	"github.com/CoffeeSwt/bilibili-tts-chat/handler/common"
+	"github.com/CoffeeSwt/bilibili-tts-chat/command"
 	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/reply_template"
 	"github.com/CoffeeSwt/bilibili-tts-chat/response"
 	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
 	"github.com/CoffeeSwt/bilibili-tts-chat/user"
 )
 
+// GiftEvent 礼物回复模板的数据，GiftNum 为合并后的礼物总数
+type GiftEvent struct {
+	response.GiftData
+	Value int // 总价值，1000 = 1元
+}
+
 // HandleGift 处理礼物消息
-// 这是礼物消息的核心处理函数，负责解析和处理用户发送的礼物
-func HandleGift(cmdData []byte) error {
+// 这是礼物消息的核心处理函数，负责解析用户发送的礼物，连击礼物会在连击结束后合并播报
+func (a *Aggregator) HandleGift(cmdData []byte) error {
 	var msg response.GiftMessage
 	if err := json.Unmarshal(cmdData, &msg); err != nil {
 		logger.Error(fmt.Sprintf("[GiftHandler] 解析礼物消息失败: %v", err))
 		return err
 	}
 
-	logger.Info(fmt.Sprintf("[礼物] 用户: %s, 礼物: %s x%d, 价值: %d, 房间: %d",
-		msg.Data.UName, msg.Data.GiftName, msg.Data.GiftNum, msg.Data.Price, msg.Data.RoomID))
+	logger.Info(fmt.Sprintf("[礼物] 用户: %s, 礼物: %s x%d, 价值: %d, 房间: %d, 连击: %s x%d",
+		msg.Data.UName, msg.Data.GiftName, msg.Data.GiftNum, msg.Data.Price, msg.Data.RoomID,
+		msg.Data.ComboInfo.ComboID, msg.Data.ComboInfo.ComboCount))
+	if msg.Data.BlindGift.Status {
+		logger.Info(fmt.Sprintf("[礼物] 盲盒: %s, 盲盒单价: %d, 开出: %s",
+			blindBoxName(msg.Data), blindBoxPrice(msg.Data), msg.Data.GiftName))
+	}
+
+	// 累计付费礼物价值，用于解锁专属音色
+	if msg.Data.Paid {
+		if err := user.AddGiftValue(msg.Data.OpenID, msg.Data.UName, msg.Data.Price*msg.Data.GiftNum); err != nil {
+			logger.Error(fmt.Sprintf("[GiftHandler] 记录用户 %s 的送礼价值失败: %v", msg.Data.UName, err))
+		}
+	}
+
+	// 大航海到期等原因不再满足专属音色的条件时换回免费音色
+	command.EnforceVoiceEligibility(a.tm, msg.Data.UName,
+		command.SupporterOf(msg.Data.OpenID, msg.Data.UName, msg.Data.GuardLevel, msg.Data.FansMedalLevel))
 
-	usingLLMReply := config.GetUseLLMReplay()
+	a.add(msg.Data)
+	return nil
+}
+
+// announce 播报一组礼物，gift.GiftNum 为礼物总数，value 为总价值
+func announce(tm *task_manager.TaskManager, gift response.GiftData, value int) {
+	usingLLMReply := tm.UseLLMReply()
 	if usingLLMReply {
 		var eventDescription string
-		if msg.Data.GiftNum > 1 {
+		if gift.GiftNum > 1 {
 			eventDescription = fmt.Sprintf("【礼物】用户 %s 送出了 %d个 %s（总价值：%d）",
-				msg.Data.UName, msg.Data.GiftNum, msg.Data.GiftName, msg.Data.Price)
+				gift.UName, gift.GiftNum, gift.GiftName, value)
 		} else {
 			eventDescription = fmt.Sprintf("【礼物】用户 %s 送出了 %s（价值：%d）",
-				msg.Data.UName, msg.Data.GiftName, msg.Data.Price)
+				gift.UName, gift.GiftName, value)
 		}
-		if err := task_manager.AddText(eventDescription, task_manager.TextTypeNormal, user.GetUserVoice(msg.Data.UName)); err != nil {
+		if err := tm.AddText(eventDescription, task_manager.TextTypeNormal, user.GetUserVoice(gift.UName)); err != nil {
 			logger.Error(fmt.Sprintf("[GiftHandler] 添加事件到任务管理器失败: %v", err))
 		}
 	} else {
-		var reply string
-		if msg.Data.GiftNum > 1 {
-			reply = fmt.Sprintf("感谢%s赠送了 %d个 %s，%s", msg.Data.UName, msg.Data.GiftNum, msg.Data.GiftName, common.RandomBlessing())
-		} else {
-			reply = fmt.Sprintf("感谢%s赠送了 %s，%s", msg.Data.UName, msg.Data.GiftName, common.RandomBlessing())
+		reply, err := reply_template.Render(reply_template.EventGift, GiftEvent{GiftData: gift, Value: value})
+		if err != nil {
+			logger.Error(fmt.Sprintf("[GiftHandler] 生成回复失败: %v", err))
+			return
 		}
-		if err := task_manager.AddText(reply, task_manager.TextTypeNoLLMReply, user.GetUserVoice(msg.Data.UName)); err != nil {
+		if err := tm.AddText(reply, task_manager.TextTypeNoLLMReply, user.GetUserVoice(gift.UName)); err != nil {
 			logger.Error(fmt.Sprintf("[GiftHandler] 添加事件到任务管理器失败: %v", err))
 		}
 	}
-
-	return nil
 }
@@ -4,9 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"github.com/CoffeeSwt/bilibili-tts-chat/config"
+	"github.com/CoffeeSwt/bilibili-tts-chat/command"
 	"github.com/CoffeeSwt/bilibili-tts-chat/handler/common"
 	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/reply_template"
 	"github.com/CoffeeSwt/bilibili-tts-chat/response"
 	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
 	"github.com/CoffeeSwt/bilibili-tts-chat/user"
@@ -14,7 +15,7 @@ import (
 
 // HandleSuperChat 处理付费留言消息
 // 这是付费留言消息的核心处理函数，负责解析和处理用户发送的付费留言
-func HandleSuperChat(cmdData []byte) error {
+func HandleSuperChat(tm *task_manager.TaskManager, cmdData []byte) error {
 	var msg response.SuperChatMessage
 	if err := json.Unmarshal(cmdData, &msg); err != nil {
 		logger.Error(fmt.Sprintf("[SuperChatHandler] 解析付费留言消息失败: %v", err))
@@ -24,16 +25,27 @@ func HandleSuperChat(cmdData []byte) error {
 	logger.Info(fmt.Sprintf("[付费留言] 用户: %s, 内容: %s, 金额: %d元, 房间: %d",
 		msg.Data.UName, msg.Data.Message, msg.Data.RMB, msg.Data.RoomID))
 
-	usingLLMReply := config.GetUseLLMReplay()
+	// 大航海到期等原因不再满足专属音色的条件时换回免费音色
+	command.EnforceVoiceEligibility(tm, msg.Data.UName,
+		command.SupporterOf(msg.Data.OpenID, msg.Data.UName, msg.Data.GuardLevel, msg.Data.FansMedalLevel))
+
+	// 留言被删除时按留言id撤回播报
+	sourceID := common.SuperChatSourceID(msg.Data.MessageID)
+
+	usingLLMReply := tm.UseLLMReply()
 	if usingLLMReply {
 		eventDescription := fmt.Sprintf("【付费留言】用户 %s 发送了 %d元 的付费留言：%s",
 			msg.Data.UName, msg.Data.RMB, msg.Data.Message)
-		if err := task_manager.AddText(eventDescription, task_manager.TextTypeNormal, user.GetUserVoice(msg.Data.UName)); err != nil {
+		if err := tm.AddTextWithSource(eventDescription, task_manager.TextTypeNormal, user.GetUserVoice(msg.Data.UName), sourceID); err != nil {
 			logger.Error(fmt.Sprintf("[SuperChatHandler] 添加事件到任务管理器失败: %v", err))
 		}
 	} else {
-		reply := fmt.Sprintf("%s的付费留言（%d元）：%s，%s", msg.Data.UName, msg.Data.RMB, msg.Data.Message, common.RandomBlessing())
-		if err := task_manager.AddText(reply, task_manager.TextTypeNoLLMReply, user.GetUserVoice(msg.Data.UName)); err != nil {
+		reply, err := reply_template.Render(reply_template.EventSuperChat, msg.Data)
+		if err != nil {
+			logger.Error(fmt.Sprintf("[SuperChatHandler] 生成回复失败: %v", err))
+			return nil
+		}
+		if err := tm.AddTextWithSource(reply, task_manager.TextTypeNoLLMReply, user.GetUserVoice(msg.Data.UName), sourceID); err != nil {
 			logger.Error(fmt.Sprintf("[SuperChatHandler] 添加事件到任务管理器失败: %v", err))
 		}
 	}
@@ -4,13 +4,15 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/CoffeeSwt/bilibili-tts-chat/handler/common"
 	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
 	"github.com/CoffeeSwt/bilibili-tts-chat/response"
+	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
 )
 
-// HandleSuperChat 处理付费留言消息
-// 这是付费留言消息的核心处理函数，负责解析和处理用户发送的付费留言
-func HandleSuperChatDel(cmdData []byte) error {
+// HandleSuperChatDel 处理付费留言下线消息
+// 留言被删除后撤回还在排队的播报，正在播放时立即停止
+func HandleSuperChatDel(tm *task_manager.TaskManager, cmdData []byte) error {
 	var msg response.SuperChatDelMessage
 	if err := json.Unmarshal(cmdData, &msg); err != nil {
 		logger.Error(fmt.Sprintf("[SuperChatDelHandler] 解析付费留言消息失败: %v", err))
@@ -19,5 +21,13 @@ func HandleSuperChatDel(cmdData []byte) error {
 
 	logger.Info(fmt.Sprintf("[付费留言下线] 房间: %d, 删除留言ID: %v",
 		msg.Data.RoomID, msg.Data.MessageIDs))
+
+	sourceIDs := make([]string, 0, len(msg.Data.MessageIDs))
+	for _, id := range msg.Data.MessageIDs {
+		sourceIDs = append(sourceIDs, common.SuperChatSourceID(id))
+	}
+	removed, interrupted := tm.Retract(sourceIDs...)
+	logger.Info(fmt.Sprintf("[付费留言下线] 已撤回播报, 留言ID: %v, 移除待播报: %d 条, 停止正在播放: %t",
+		msg.Data.MessageIDs, removed, interrupted))
 	return nil
 }
@@ -1,29 +1,95 @@
 package llm
 
 import (
+	"slices"
 	"sync"
 
 	"github.com/CoffeeSwt/bilibili-tts-chat/config"
 )
 
+// Memory 助手的短期记忆，保存最近的事件和回复，用于生成提示词
+type Memory struct {
+	mu   sync.Mutex
+	size int
+	data []memoryEntry
+}
+
+// memoryEntry 一条记忆
+type memoryEntry struct {
+	text      string
+	sourceIDs []string // 来源消息id，用于撤回，为空时不能撤回
+}
+
+// NewMemory 创建记忆，size 为记忆条数，小于等于0时使用 config.GetAssistantMemorySize()
+func NewMemory(size int) *Memory {
+	return &Memory{
+		size: size,
+		data: make([]memoryEntry, 0),
+	}
+}
+
+// Add 添加一条记忆，超出容量时丢弃最早的记忆
+func (m *Memory) Add(eventData string) {
+	m.AddWithSource(eventData)
+}
+
+// AddWithSource 添加一条记忆，并记录来源消息id，任一来源消息被撤回时可以通过 Remove 移除
+func (m *Memory) AddWithSource(eventData string, sourceIDs ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	size := m.size
+	if size <= 0 {
+		size = config.GetAssistantMemorySize()
+	}
+	if len(m.data) >= size && len(m.data) > 0 {
+		m.data = m.data[1:]
+	}
+	ids := slices.DeleteFunc(slices.Clone(sourceIDs), func(id string) bool { return id == "" })
+	m.data = append(m.data, memoryEntry{text: eventData, sourceIDs: ids})
+}
+
+// Remove 移除来源消息对应的记忆，返回移除的条数
+func (m *Memory) Remove(sourceIDs ...string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	before := len(m.data)
+	m.data = slices.DeleteFunc(m.data, func(e memoryEntry) bool {
+		return slices.ContainsFunc(e.sourceIDs, func(id string) bool { return slices.Contains(sourceIDs, id) })
+	})
+	return before - len(m.data)
+}
+
+// Get 返回所有记忆的副本
+func (m *Memory) Get() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data := make([]string, len(m.data))
+	for i, e := range m.data {
+		data[i] = e.text
+	}
+	return data
+}
+
 var (
-	cache_event_Data []string
-	onceCache        sync.Once
+	defaultMemory *Memory
+	onceCache     sync.Once
 )
 
-func AddCacheEventData(eventData string) {
+// DefaultMemory 获取默认直播间使用的记忆
+func DefaultMemory() *Memory {
 	onceCache.Do(func() {
-		cache_event_Data = make([]string, 0)
+		defaultMemory = NewMemory(0)
 	})
-	if len(cache_event_Data) >= config.GetAssistantMemorySize() {
-		cache_event_Data = cache_event_Data[1:]
-	}
-	cache_event_Data = append(cache_event_Data, eventData)
+	return defaultMemory
+}
+
+func AddCacheEventData(eventData string) {
+	DefaultMemory().Add(eventData)
 }
 
 func GetCacheEventData() []string {
-	onceCache.Do(func() {
-		cache_event_Data = make([]string, 0)
-	})
-	return cache_event_Data
+	return DefaultMemory().Get()
 }
@@ -86,14 +86,22 @@ func analyzeGiftValue(eventContent string) string {
 	return "普通"
 }
 
-// buildRoomContext 构造直播间环境信息
-func buildRoomContext() string {
-	return config.GetRoomDescription()
+// Persona 助手人设，多直播间时每个直播间可以有不同的人设
+type Persona struct {
+	AssistantName   string // 助手名称
+	RoomDescription string // 直播间描述
+}
+
+// DefaultPersona 使用 user.json 中的配置构造人设
+func DefaultPersona() Persona {
+	return Persona{
+		AssistantName:   config.GetAssistantName(),
+		RoomDescription: config.GetRoomDescription(),
+	}
 }
 
 // getEventSpecificPrompt 根据事件类型获取专门的提示词
-func getEventSpecificPrompt(eventType EventType, eventContent string) string {
-	assistantName := config.GetAssistantName()
+func getEventSpecificPrompt(assistantName string, eventType EventType, eventContent string) string {
 
 	switch eventType {
 	case EventDanmaku:
@@ -201,6 +209,11 @@ func getEventSpecificPrompt(eventType EventType, eventContent string) string {
 
 // GeneratePrompt 生成专门针对B站直播环境的AI提示词
 func GeneratePrompt(msgs []string) string {
+	return GeneratePromptForPersona(DefaultPersona(), GetCacheEventData(), msgs)
+}
+
+// GeneratePromptForPersona 使用指定的人设和记忆生成提示词
+func GeneratePromptForPersona(persona Persona, memory []string, msgs []string) string {
 	if config.IsDev() {
 		for i, msg := range msgs {
 			logger.Debug("事件消息", "index", i, "content", msg)
@@ -208,14 +221,14 @@ func GeneratePrompt(msgs []string) string {
 	}
 
 	// 获取直播间信息
-	roomDescription := buildRoomContext()
+	roomDescription := persona.RoomDescription
 
 	// 构建事件内容
 	eventContent := strings.Join(msgs, " ")
 
 	// 分析事件类型
 	eventType := analyzeEventType(msgs)
-	eventSpecificPrompt := getEventSpecificPrompt(eventType, eventContent)
+	eventSpecificPrompt := getEventSpecificPrompt(persona.AssistantName, eventType, eventContent)
 
 	// 根据事件类型确定回复长度要求
 	lengthRequirement := "20-35字"
@@ -225,11 +238,10 @@ func GeneratePrompt(msgs []string) string {
 		lengthRequirement = "25-40字"
 	}
 
-	cacheEventData := GetCacheEventData()
-	cacheEventDataStr := strings.Join(cacheEventData, "\n")
+	cacheEventDataStr := strings.Join(memory, "\n")
 
 	// 获取助手名字
-	assistantName := config.GetAssistantName()
+	assistantName := persona.AssistantName
 
 	// 优化后的B站直播助播AI提示词
 	prompt := fmt.Sprintf(`你是B站直播间的助播%s，作为独立的个体参与直播间互动，帮助提升直播间氛围。
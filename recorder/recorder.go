@@ -0,0 +1,178 @@
+package recorder
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+)
+
+// Record 录制文件中的一行，对应一条到达消息处理器的原始消息
+type Record struct {
+	ReceivedAt time.Time       `json:"received_at"` // 收到消息的时间
+	Cmd        string          `json:"cmd"`         // 消息类型
+	Body       json.RawMessage `json:"body"`        // 原始消息体
+}
+
+// Session 一个录制会话，对应一个录制文件
+type Session struct {
+	mu     sync.Mutex
+	path   string
+	file   *os.File
+	writer *bufio.Writer
+	closed bool
+}
+
+// StartSession 开始新的录制会话
+// 文件位于工作目录的 recordings 目录下，label 不为空时会加入文件名，用于区分多个直播间
+func StartSession(label string) (*Session, error) {
+	workDir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("获取工作目录失败: %v", err)
+	}
+	dir := filepath.Join(workDir, "recordings")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建录制目录失败: %v", err)
+	}
+
+	name := "session"
+	if label != "" {
+		name += "_" + label
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s_%s.jsonl", name, time.Now().Format("2006-01-02_150405")))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("创建录制文件失败: %v", err)
+	}
+
+	logger.Info(fmt.Sprintf("[Recorder] 开始录制直播事件: %s", path))
+	return &Session{
+		path:   path,
+		file:   file,
+		writer: bufio.NewWriter(file),
+	}, nil
+}
+
+// Path 返回录制文件路径
+func (s *Session) Path() string {
+	return s.path
+}
+
+// Write 录制一条消息，会话为空或已结束时忽略
+func (s *Session) Write(cmd string, body []byte) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	line, err := json.Marshal(Record{
+		ReceivedAt: time.Now(),
+		Cmd:        cmd,
+		Body:       body,
+	})
+	if err != nil {
+		logger.Error(fmt.Sprintf("[Recorder] 序列化录制数据失败: %v", err))
+		return
+	}
+
+	s.writer.Write(line)
+	s.writer.WriteByte('\n')
+	// 立即刷新，确保异常退出时也能保留已收到的事件
+	if err := s.writer.Flush(); err != nil {
+		logger.Error(fmt.Sprintf("[Recorder] 写入录制文件失败: %v", err))
+	}
+}
+
+// Close 结束录制会话
+func (s *Session) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	err := s.writer.Flush()
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+	logger.Info(fmt.Sprintf("[Recorder] 录制已结束: %s", s.path))
+	return err
+}
+
+// Replay 按录制时的时间间隔回放录制文件，handle 为消息处理函数
+// speed 为回放倍速，1为实时，2为两倍速，小于等于0时不等待直接回放
+// 返回成功回放的消息数
+func Replay(ctx context.Context, path string, speed float64, handle func(body []byte) error) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("打开录制文件失败: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	logger.Info(fmt.Sprintf("[Recorder] 开始回放: %s (倍速: %v)", path, speed))
+
+	count := 0
+	lineNo := 0
+	var last time.Time
+	for scanner.Scan() {
+		lineNo++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			logger.Warn(fmt.Sprintf("[Recorder] 跳过第 %d 行无法解析的数据: %v", lineNo, err))
+			continue
+		}
+
+		if speed > 0 && !last.IsZero() {
+			if gap := record.ReceivedAt.Sub(last); gap > 0 {
+				select {
+				case <-ctx.Done():
+					return count, ctx.Err()
+				case <-time.After(time.Duration(float64(gap) / speed)):
+				}
+			}
+		}
+		last = record.ReceivedAt
+
+		select {
+		case <-ctx.Done():
+			return count, ctx.Err()
+		default:
+		}
+
+		if err := handle(record.Body); err != nil {
+			logger.Error(fmt.Sprintf("[Recorder] 回放第 %d 行失败: %v", lineNo, err))
+			continue
+		}
+		count++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return count, fmt.Errorf("读取录制文件失败: %v", err)
+	}
+
+	logger.Info(fmt.Sprintf("[Recorder] 回放完成，共 %d 条消息", count))
+	return count, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/bili"
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+)
+
+// 回放录制的直播事件，复现直播中遇到的问题
+// 用法: go run ./recorder/replay_example -file recordings/session_xxx.jsonl -speed 2
+func main() {
+	file := flag.String("file", "", "录制文件路径（recordings 目录下的 jsonl 文件）")
+	speed := flag.Float64("speed", 1, "回放倍速，1为实时，0为不等待")
+	flag.Parse()
+
+	if *file == "" {
+		fmt.Println("请通过 -file 指定录制文件")
+		os.Exit(1)
+	}
+
+	appManager := bili.NewAppManager()
+	done, err := appManager.StartReplay(*file, *speed)
+	if err != nil {
+		logger.Error("启动回放失败", "error", err)
+		return
+	}
+	defer appManager.Stop()
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case <-done:
+	case <-c:
+		return
+	}
+
+	// 回放结束后等待合并中的礼物、点赞和剩余的播报任务完成
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			stats := appManager.GetStats()
+			if stats["gift_combos_pending"] == 0 && stats["likes_pending"] == 0 &&
+				!appManager.Tasks().IsTaskRunning() && appManager.Tasks().GetWindowSize() == 0 {
+				logger.Info("回放完成")
+				return
+			}
+		case <-c:
+			return
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package reply_template
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/config"
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+)
+
+// FileName 用户模板文件名，从工作目录向上查找
+const FileName = "reply_templates.yaml"
+
+// reloadInterval 检查模板文件是否修改的间隔
+const reloadInterval = 2 * time.Second
+
+var (
+	defaultSet  *Set
+	defaultOnce sync.Once
+
+	userTemplates loader
+)
+
+// defaults 返回内置的模板
+func defaults() *Set {
+	defaultOnce.Do(func() {
+		var err error
+		if defaultSet, err = Parse(defaultTemplates); err != nil {
+			panic(fmt.Sprintf("内置回复模板错误: %v", err))
+		}
+	})
+	return defaultSet
+}
+
+// loader 加载用户模板文件，文件修改后自动重新加载
+type loader struct {
+	mu      sync.Mutex
+	checked time.Time // 上次检查文件的时间
+	path    string
+	modTime time.Time
+	set     *Set // 没有模板文件时为空
+}
+
+// current 返回当前的用户模板，距上次检查超过 reloadInterval 时检查文件是否修改
+func (l *loader) current() *Set {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if time.Since(l.checked) < reloadInterval {
+		return l.set
+	}
+	l.checked = time.Now()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return l.set
+	}
+	path, ok := config.FindFileUpwardsProxy(wd, FileName)
+	if !ok {
+		if l.set != nil {
+			logger.Info("[回复模板] 模板文件已删除，使用内置模板")
+		}
+		l.path, l.modTime, l.set = "", time.Time{}, nil
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil || (path == l.path && info.ModTime().Equal(l.modTime)) {
+		return l.set
+	}
+
+	// 无论成功与否都记录修改时间，文件出错时不会反复解析
+	l.path, l.modTime = path, info.ModTime()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[回复模板] 读取模板文件失败: %v", err))
+		return l.set
+	}
+	set, err := Parse(data)
+	if err != nil {
+		logger.Error(fmt.Sprintf("[回复模板] %v，继续使用之前的模板", err))
+		return l.set
+	}
+	l.set = set
+	logger.Info(fmt.Sprintf("[回复模板] 已加载模板文件: %s", path))
+	return l.set
+}
+
+// Render 渲染事件的回复，优先使用用户模板文件，文件中没有该事件或者模板出错时使用内置模板
+func Render(event string, data any) (string, error) {
+	if set := userTemplates.current(); set != nil {
+		text, err := set.Render(event, data)
+		if err == nil {
+			return text, nil
+		}
+		if len(set.events[event]) > 0 {
+			logger.Warn(fmt.Sprintf("[回复模板] %v，使用内置模板", err))
+		}
+	}
+	return defaults().Render(event, data)
+}
+
+// RandomBlessing 随机返回一句祝福语，优先使用用户模板文件中的祝福语
+func RandomBlessing() string {
+	if set := userTemplates.current(); set != nil && len(set.blessings) > 0 {
+		return set.RandomBlessing()
+	}
+	return defaults().RandomBlessing()
+}
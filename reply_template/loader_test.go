@@ -0,0 +1,64 @@
+package reply_template
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoaderBrokenFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	path := filepath.Join(dir, FileName)
+
+	var l loader
+	write := func(content string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		l.checked = time.Time{}
+	}
+	now := time.Now()
+
+	// 第一次加载就出错时没有用户模板，使用内置模板
+	write("events: [", now.Add(-time.Minute))
+	if set := l.current(); set != nil {
+		t.Fatal("模板文件出错时不应返回用户模板")
+	}
+
+	write("events:\n  danmaku:\n    - text: '自定义 {{.UName}}'\n", now.Add(-30*time.Second))
+	set := l.current()
+	if set == nil {
+		t.Fatal("应加载用户模板")
+	}
+
+	// 修改出错时继续使用之前的模板
+	write("events:\n  danmaku:\n    - text: '{{.UName'\n", now)
+	if got := l.current(); got != set {
+		t.Fatal("模板文件出错时应继续使用之前的模板")
+	}
+}
+
+func TestRenderFallsBackToBuiltin(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte("events: ["), 0644); err != nil {
+		t.Fatal(err)
+	}
+	userTemplates = loader{}
+	t.Cleanup(func() { userTemplates = loader{} })
+
+	data := struct{ UName, Msg, ReplyUName string }{UName: "小明", Msg: "主播好"}
+	got, err := Render(EventDanmaku, data)
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if want := "小明说：主播好"; got != want {
+		t.Errorf("Render = %q, want %q", got, want)
+	}
+}
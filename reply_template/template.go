@@ -0,0 +1,167 @@
+package reply_template
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+// 事件类型，对应模板文件中 events 下的键
+const (
+	EventDanmaku   = "danmaku"
+	EventEmoji     = "emoji"
+	EventGift      = "gift"
+	EventBlindBox  = "blind_box"
+	EventSuperChat = "super_chat"
+	EventGuard     = "guard"
+	EventLike      = "like"
+	EventEnter     = "enter"
+	EventLiveStart = "live_start"
+	EventLiveEnd   = "live_end"
+
+	EventVoicePreview = "voice_preview" // 试听音色的示例句子，开启LLM回复时也使用
+)
+
+//go:embed reply_templates.yaml
+var defaultTemplates []byte
+
+// variant 一个事件的一种模板
+type variant struct {
+	When string `yaml:"when"` // 条件，为空时总是可用
+	Text string `yaml:"text"`
+
+	when *template.Template
+	text *template.Template
+}
+
+// templateFile 模板文件的结构
+type templateFile struct {
+	Blessings []string              `yaml:"blessings"`
+	Events    map[string][]*variant `yaml:"events"`
+}
+
+// Set 解析后的一组模板
+type Set struct {
+	blessings []string
+	events    map[string][]*variant
+}
+
+// Parse 解析YAML格式的模板文件
+func Parse(data []byte) (*Set, error) {
+	var file templateFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析模板文件失败: %v", err)
+	}
+
+	s := &Set{blessings: file.Blessings, events: file.Events}
+	funcs := template.FuncMap{
+		"blessing": func() string {
+			// 模板文件中没有祝福语时使用内置的祝福语
+			if len(s.blessings) > 0 {
+				return s.RandomBlessing()
+			}
+			return RandomBlessing()
+		},
+		"pick":    pick,
+		"yuan":    formatYuan,
+		"battery": formatBattery,
+	}
+	for event, variants := range s.events {
+		for i, v := range variants {
+			if v == nil || strings.TrimSpace(v.Text) == "" {
+				return nil, fmt.Errorf("事件 %s 的第%d个模板内容为空", event, i+1)
+			}
+			name := fmt.Sprintf("%s[%d]", event, i+1)
+			var err error
+			if v.text, err = template.New(name).Funcs(funcs).Parse(v.Text); err != nil {
+				return nil, fmt.Errorf("解析模板 %s 失败: %v", name, err)
+			}
+			if v.When != "" {
+				if v.when, err = template.New(name + ".when").Funcs(funcs).Parse(v.When); err != nil {
+					return nil, fmt.Errorf("解析模板 %s 的条件失败: %v", name, err)
+				}
+			}
+		}
+	}
+	return s, nil
+}
+
+// Render 用事件数据渲染事件的模板
+// 有条件成立的模板时从中随机选择一个，否则从没有条件的模板中随机选择
+func (s *Set) Render(event string, data any) (string, error) {
+	variants := s.events[event]
+	if len(variants) == 0 {
+		return "", fmt.Errorf("事件 %s 没有模板", event)
+	}
+
+	var matched, fallback []*variant
+	for _, v := range variants {
+		if v.when == nil {
+			fallback = append(fallback, v)
+			continue
+		}
+		ok, err := execute(v.when, data)
+		if err != nil {
+			return "", err
+		}
+		if ok == "true" {
+			matched = append(matched, v)
+		}
+	}
+	if len(matched) == 0 {
+		matched = fallback
+	}
+	if len(matched) == 0 {
+		return "", fmt.Errorf("事件 %s 没有可用的模板", event)
+	}
+
+	text, err := execute(matched[rand.Intn(len(matched))].text, data)
+	if err != nil {
+		return "", err
+	}
+	if text == "" {
+		return "", fmt.Errorf("事件 %s 的模板结果为空", event)
+	}
+	return text, nil
+}
+
+// RandomBlessing 随机返回一句祝福语，没有祝福语时返回空字符串
+func (s *Set) RandomBlessing() string {
+	if len(s.blessings) == 0 {
+		return ""
+	}
+	return s.blessings[rand.Intn(len(s.blessings))]
+}
+
+// execute 执行模板并去掉首尾空白
+func execute(t *template.Template, data any) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("执行模板 %s 失败: %v", t.Name(), err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// pick 从参数中随机选择一个
+func pick(options ...string) string {
+	if len(options) == 0 {
+		return ""
+	}
+	return options[rand.Intn(len(options))]
+}
+
+// formatYuan 把礼物价值转换为元，1000 = 1元，保留两位小数并去掉多余的0
+func formatYuan(value int) string {
+	return strconv.FormatFloat(math.Round(float64(value)/10)/100, 'f', -1, 64)
+}
+
+// formatBattery 把礼物价值转换为电池，1000 = 10电池
+func formatBattery(value int) string {
+	return strconv.FormatFloat(float64(value)/100, 'f', -1, 64)
+}
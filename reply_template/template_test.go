@@ -0,0 +1,177 @@
+package reply_template_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/command"
+	"github.com/CoffeeSwt/bilibili-tts-chat/handler/dm"
+	"github.com/CoffeeSwt/bilibili-tts-chat/handler/guard"
+	"github.com/CoffeeSwt/bilibili-tts-chat/handler/like"
+	"github.com/CoffeeSwt/bilibili-tts-chat/handler/live_end"
+	"github.com/CoffeeSwt/bilibili-tts-chat/handler/live_room_enter"
+	"github.com/CoffeeSwt/bilibili-tts-chat/handler/send_gift"
+	"github.com/CoffeeSwt/bilibili-tts-chat/reply_template"
+	"github.com/CoffeeSwt/bilibili-tts-chat/response"
+)
+
+// builtin 解析内置的模板文件
+func builtin(t *testing.T) *reply_template.Set {
+	t.Helper()
+	data, err := os.ReadFile(reply_template.FileName)
+	if err != nil {
+		t.Fatalf("读取内置模板失败: %v", err)
+	}
+	set, err := reply_template.Parse(data)
+	if err != nil {
+		t.Fatalf("解析内置模板失败: %v", err)
+	}
+	return set
+}
+
+func TestBuiltinTemplates(t *testing.T) {
+	set := builtin(t)
+
+	tests := []struct {
+		event string
+		data  any
+		want  string // 渲染结果应包含的内容
+	}{
+		{
+			event: reply_template.EventDanmaku,
+			data:  response.DanmakuData{UName: "小明", Msg: "主播好"},
+			want:  "小明说：主播好",
+		},
+		{
+			event: reply_template.EventEmoji,
+			data:  dm.EmojiEvent{UName: "小明", Name: "赞", Names: "[赞] x2、[打call]", Count: 3},
+			want:  "3个表情：[赞] x2、[打call]",
+		},
+		{
+			event: reply_template.EventGift,
+			data: send_gift.GiftEvent{
+				GiftData: response.GiftData{UName: "小明", GiftName: "小花花", GiftNum: 10, Paid: true},
+				Value:    1000,
+			},
+			want: "10个 小花花，共1元",
+		},
+		{
+			event: reply_template.EventBlindBox,
+			data: send_gift.BlindBoxEvent{
+				GiftData:   response.GiftData{UName: "小明"},
+				Box:        "心动盲盒",
+				Count:      2,
+				Items:      "小花花 x2",
+				Priced:     true,
+				ProfitText: "赚了10电池",
+			},
+			want: "开了2个心动盲盒，开出了小花花 x2，赚了10电池",
+		},
+		{
+			event: reply_template.EventSuperChat,
+			data:  response.SuperChatData{UName: "小明", Message: "加油", RMB: 30},
+			want:  "小明的付费留言（30元）：加油",
+		},
+		{
+			event: reply_template.EventGuard,
+			data: guard.GuardEvent{
+				GuardData: response.GuardData{UserInfo: response.UserInfo{UName: "小明"}},
+				GuardName: "舰长",
+				Duration:  "一个月的",
+			},
+			want: "感谢小明给主播赠送了一个月的舰长",
+		},
+		{
+			event: reply_template.EventLike,
+			data:  like.LikeEvent{Who: "小明、小红", Total: 3, Users: 2},
+			want:  "感谢小明、小红一共点了3个赞",
+		},
+		{
+			event: reply_template.EventEnter,
+			data:  live_room_enter.EnterEvent{RoomEnterData: response.RoomEnterData{UName: "小明"}, FirstVisit: true},
+			want:  "欢迎小明第一次来到直播间",
+		},
+		{
+			event: reply_template.EventLiveStart,
+			data:  response.LiveStartData{RoomID: 123},
+			want:  "房间号：123",
+		},
+		{
+			event: reply_template.EventLiveEnd,
+			data:  live_end.LiveEndEvent{Report: live_end.Report{DanmakuCount: 5, UniqueViewers: 2}, Supporters: "小明"},
+			want:  "共收到5条弹幕，有2位观众来过，特别感谢小明的支持",
+		},
+		{
+			event: reply_template.EventVoicePreview,
+			data:  command.VoicePreviewEvent{UName: "小明", VoiceName: "湾湾小何"},
+			want:  "我是湾湾小何",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.event, func(t *testing.T) {
+			got, err := set.Render(tt.event, tt.data)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("Render = %q, want 包含 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+const conditionalTemplates = `
+events:
+  gift:
+    - when: '{{ge .Value 100000}}'
+      text: '大额 {{.UName}}'
+    - when: '{{eq .GiftName "小心心"}}'
+      text: '小心心 {{.UName}}'
+    - text: '普通 {{.UName}}'
+  guard:
+    - when: '{{eq .GuardLevel 1}}'
+      text: '总督 {{.UserInfo.UName}}'
+`
+
+func TestRenderWhen(t *testing.T) {
+	set, err := reply_template.Parse([]byte(conditionalTemplates))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		event string
+		data  any
+		want  string
+	}{
+		{name: "条件成立", event: reply_template.EventGift, data: send_gift.GiftEvent{GiftData: response.GiftData{UName: "小明"}, Value: 200000}, want: "大额 小明"},
+		{name: "另一个条件成立", event: reply_template.EventGift, data: send_gift.GiftEvent{GiftData: response.GiftData{UName: "小明", GiftName: "小心心"}}, want: "小心心 小明"},
+		{name: "没有条件成立时使用无条件模板", event: reply_template.EventGift, data: send_gift.GiftEvent{GiftData: response.GiftData{UName: "小明"}, Value: 1000}, want: "普通 小明"},
+		{name: "只有条件模板", event: reply_template.EventGuard, data: guard.GuardEvent{GuardData: response.GuardData{GuardLevel: 1, UserInfo: response.UserInfo{UName: "小明"}}}, want: "总督 小明"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 模板随机选择，多次渲染确认结果稳定
+			for range 20 {
+				got, err := set.Render(tt.event, tt.data)
+				if err != nil {
+					t.Fatalf("Render: %v", err)
+				}
+				if got != tt.want {
+					t.Fatalf("Render = %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+
+	// 没有条件成立也没有无条件模板时返回错误，由调用方使用内置模板
+	data := guard.GuardEvent{GuardData: response.GuardData{GuardLevel: 3}}
+	if got, err := set.Render(reply_template.EventGuard, data); err == nil {
+		t.Errorf("没有可用模板时 Render = %q, want 错误", got)
+	}
+	if got, err := set.Render(reply_template.EventLike, like.LikeEvent{}); err == nil {
+		t.Errorf("没有该事件的模板时 Render = %q, want 错误", got)
+	}
+}
@@ -51,6 +51,8 @@ type ComboInfo struct {
 
 // 盲盒信息结构体
 type BlindGift struct {
-	BlindGiftID int  `json:"blind_gift_id"` // 盲盒id
-	Status      bool `json:"status"`        // 是否是盲盒
+	BlindGiftID       int    `json:"blind_gift_id"`       // 盲盒id
+	Status            bool   `json:"status"`              // 是否是盲盒
+	OriginalGiftName  string `json:"original_gift_name"`  // 盲盒名，没有时为空
+	OriginalGiftPrice int    `json:"original_gift_price"` // 盲盒单价(1000 = 1元 = 10电池)，没有时为0，按 user.json 的 blind_box_prices 计算盈亏
 }
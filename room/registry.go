@@ -0,0 +1,286 @@
+// Package room 多直播间管理，每个直播间拥有独立的身份码、场次、事件队列、助手人设和音频输出
+package room
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/bili"
+	"github.com/CoffeeSwt/bilibili-tts-chat/config"
+	"github.com/CoffeeSwt/bilibili-tts-chat/eventsource"
+	"github.com/CoffeeSwt/bilibili-tts-chat/llm"
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/task_manager"
+	"github.com/CoffeeSwt/bilibili-tts-chat/voice"
+)
+
+// Room 一个直播间
+type Room struct {
+	mu    sync.Mutex
+	cfg   config.RoomConfig
+	tasks *task_manager.TaskManager
+	app   *bili.AppManager // 运行中的应用管理器，停止后为空
+}
+
+// Status 直播间状态
+type Status struct {
+	Name       string // 直播间名称
+	RoomIDCode string // 直播间身份码
+	Output     string // 音频输出方式
+	Running    bool   // 是否正在运行
+	WindowSize int    // 等待播报的事件数
+
+	Stats map[string]interface{} // 运行中的统计信息，包括消息去重命中数
+}
+
+// Registry 直播间注册表，直播间可以单独启动和停止
+type Registry struct {
+	mu    sync.RWMutex
+	rooms map[string]*Room
+	order []string // 添加顺序，用于列出直播间
+}
+
+// NewRegistry 创建直播间注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		rooms: make(map[string]*Room),
+	}
+}
+
+// Add 添加直播间，不会自动启动
+func (r *Registry) Add(cfg config.RoomConfig) error {
+	if cfg.Name == "" {
+		return fmt.Errorf("直播间名称不能为空")
+	}
+	if cfg.RoomIDCode == "" {
+		return fmt.Errorf("直播间 %s 的身份码不能为空", cfg.Name)
+	}
+
+	sink, err := newSink(cfg)
+	if err != nil {
+		return fmt.Errorf("直播间 %s 创建音频输出失败: %w", cfg.Name, err)
+	}
+
+	persona := llm.DefaultPersona()
+	if cfg.AssistantName != "" {
+		persona.AssistantName = cfg.AssistantName
+	}
+	if cfg.RoomDescription != "" {
+		persona.RoomDescription = cfg.RoomDescription
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.rooms[cfg.Name]; exists {
+		return fmt.Errorf("直播间已存在: %s", cfg.Name)
+	}
+	r.rooms[cfg.Name] = &Room{
+		cfg: cfg,
+		tasks: task_manager.NewTaskManager(task_manager.Options{
+			Name:    cfg.Name,
+			Persona: &persona,
+			Memory:  llm.NewMemory(cfg.AssistantMemorySize),
+			Sink:    sink,
+			Volume:  cfg.Volume,
+		}),
+	}
+	r.order = append(r.order, cfg.Name)
+	logger.Info(fmt.Sprintf("[Room] 已添加直播间: %s", cfg.Name))
+	return nil
+}
+
+// Remove 停止并移除直播间
+func (r *Registry) Remove(name string) error {
+	room, err := r.get(name)
+	if err != nil {
+		return err
+	}
+	if err := room.stop(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.rooms, name)
+	for i, n := range r.order {
+		if n == name {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+	logger.Info(fmt.Sprintf("[Room] 已移除直播间: %s", name))
+	return nil
+}
+
+// Start 启动直播间
+func (r *Registry) Start(name string) error {
+	room, err := r.get(name)
+	if err != nil {
+		return err
+	}
+	return room.start()
+}
+
+// Stop 停止直播间
+func (r *Registry) Stop(name string) error {
+	room, err := r.get(name)
+	if err != nil {
+		return err
+	}
+	return room.stop()
+}
+
+// SendDanmaku 向运行中的直播间注入一条手动弹幕
+func (r *Registry) SendDanmaku(name, uname, msg string) error {
+	room, err := r.get(name)
+	if err != nil {
+		return err
+	}
+
+	room.mu.Lock()
+	app := room.app
+	room.mu.Unlock()
+	if app == nil {
+		return fmt.Errorf("直播间 %s 未运行", name)
+	}
+	return app.SendDanmaku(uname, msg)
+}
+
+// StartAutoStart 启动所有配置了 auto_start 的直播间，单个直播间失败不影响其他直播间
+func (r *Registry) StartAutoStart() {
+	for _, name := range r.names() {
+		room, err := r.get(name)
+		if err != nil || !room.cfg.AutoStart {
+			continue
+		}
+		if err := room.start(); err != nil {
+			logger.Error(fmt.Sprintf("[Room] 启动直播间 %s 失败: %v", name, err))
+		}
+	}
+}
+
+// StopAll 停止所有直播间
+func (r *Registry) StopAll() {
+	for _, name := range r.names() {
+		if err := r.Stop(name); err != nil {
+			logger.Error(fmt.Sprintf("[Room] 停止直播间 %s 失败: %v", name, err))
+		}
+	}
+}
+
+// List 按添加顺序返回所有直播间的状态
+func (r *Registry) List() []Status {
+	names := r.names()
+	result := make([]Status, 0, len(names))
+	for _, name := range names {
+		if room, err := r.get(name); err == nil {
+			result = append(result, room.status())
+		}
+	}
+	return result
+}
+
+// get 获取直播间
+func (r *Registry) get(name string) (*Room, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	room, exists := r.rooms[name]
+	if !exists {
+		return nil, fmt.Errorf("直播间不存在: %s", name)
+	}
+	return room, nil
+}
+
+// names 返回直播间名称的副本
+func (r *Registry) names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, len(r.order))
+	copy(names, r.order)
+	return names
+}
+
+// start 启动直播间，每次启动都会创建新的应用管理器
+func (room *Room) start() error {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if room.app != nil {
+		return fmt.Errorf("直播间 %s 已经在运行中", room.cfg.Name)
+	}
+
+	app := bili.NewAppManagerWithOptions(bili.AppOptions{
+		Name:       room.cfg.Name,
+		RoomIDCode: room.cfg.RoomIDCode,
+		Tasks:      room.tasks,
+		Sources: eventsource.Options{
+			WebhookAddr:  room.cfg.WebhookAddr,
+			WebhookToken: room.cfg.WebhookToken,
+			TailFile:     room.cfg.TailFile,
+		}.Sources(),
+	})
+	if err := app.Start(); err != nil {
+		return err
+	}
+	room.app = app
+	logger.Info(fmt.Sprintf("[Room] 直播间 %s 已启动", room.cfg.Name))
+	return nil
+}
+
+// stop 停止直播间，未运行时直接返回
+func (room *Room) stop() error {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if room.app == nil {
+		return nil
+	}
+	if err := room.app.Stop(); err != nil {
+		return err
+	}
+	room.app = nil
+	logger.Info(fmt.Sprintf("[Room] 直播间 %s 已停止", room.cfg.Name))
+	return nil
+}
+
+// status 返回直播间状态
+func (room *Room) status() Status {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	output := room.cfg.Output
+	if output == "" {
+		output = config.RoomOutputDevice
+	}
+	status := Status{
+		Name:       room.cfg.Name,
+		RoomIDCode: room.cfg.RoomIDCode,
+		Output:     output,
+		Running:    room.app != nil,
+		WindowSize: room.tasks.GetWindowSize(),
+	}
+	if room.app != nil {
+		status.Stats = room.app.GetStats()
+	}
+	return status
+}
+
+// newSink 根据配置创建音频输出
+func newSink(cfg config.RoomConfig) (voice.Sink, error) {
+	switch cfg.Output {
+	case "", config.RoomOutputDevice:
+		return voice.DeviceSink{}, nil
+	case config.RoomOutputFile:
+		dir := cfg.OutputDir
+		if dir == "" {
+			dir = filepath.Join("audio", cfg.Name)
+		}
+		return voice.NewFileSink(dir)
+	default:
+		return nil, fmt.Errorf("未知的音频输出方式: %s", cfg.Output)
+	}
+}
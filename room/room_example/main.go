@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/config"
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/room"
+)
+
+// 按 rooms.json 同时运行多个直播间
+// 用法: go run ./room/room_example
+func main() {
+	rooms, err := config.LoadRoomConfigs()
+	if err != nil {
+		logger.Error("加载直播间配置失败", "error", err)
+		return
+	}
+
+	registry := room.NewRegistry()
+	for _, cfg := range rooms {
+		if err := registry.Add(cfg); err != nil {
+			logger.Error("添加直播间失败", "error", err)
+		}
+	}
+	registry.StartAutoStart()
+	defer registry.StopAll()
+
+	for _, status := range registry.List() {
+		logger.Info(fmt.Sprintf("直播间 %s: 运行中=%v, 音频输出=%s", status.Name, status.Running, status.Output))
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+	<-c
+}
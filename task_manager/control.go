@@ -0,0 +1,169 @@
+package task_manager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/config"
+	"github.com/CoffeeSwt/bilibili-tts-chat/filter"
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+)
+
+// Skip 停止正在播放的语音，没有语音在播放时返回false
+func (tm *TaskManager) Skip() bool {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	if tm.playCancel == nil {
+		return false
+	}
+	tm.playCancel()
+	logger.Info(fmt.Sprintf("%s跳过正在播放的语音", tm.logPrefix()))
+	return true
+}
+
+// ClearQueue 清空还没有播报的文本，包括已取出正在生成语音的文本，正在播放的语音不受影响
+// 返回窗口中被清空的文本数
+func (tm *TaskManager) ClearQueue() int {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	cleared := len(tm.textWindow)
+	tm.textWindow = tm.textWindow[:0]
+	if tm.currentTask != nil {
+		tm.currentTask.Texts = tm.currentTask.Texts[:0]
+	}
+	tm.batchCleared = true
+
+	logger.Info(fmt.Sprintf("%s清空待播报文本 %d 条", tm.logPrefix(), cleared))
+	return cleared
+}
+
+// Pause 暂停播报，正在播放的语音播完后不再播放新的语音，文本继续进入窗口等待恢复
+func (tm *TaskManager) Pause() {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	if tm.resumed != nil {
+		return
+	}
+	tm.resumed = make(chan struct{})
+	logger.Info(fmt.Sprintf("%s播报已暂停", tm.logPrefix()))
+}
+
+// Resume 恢复播报
+func (tm *TaskManager) Resume() {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	if tm.resumed == nil {
+		return
+	}
+	close(tm.resumed)
+	tm.resumed = nil
+	logger.Info(fmt.Sprintf("%s播报已恢复", tm.logPrefix()))
+}
+
+// IsPaused 播报是否已暂停
+func (tm *TaskManager) IsPaused() bool {
+	tm.mutex.RLock()
+	defer tm.mutex.RUnlock()
+	return tm.resumed != nil
+}
+
+// Announce 播报房管指令的回复，当前这句播完后优先于窗口中的文本播报
+// 回复不受清空影响，暂停时等待恢复，也可以被跳过
+func (tm *TaskManager) Announce(text string, v *config.Voice) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	tm.announcements = append(tm.announcements, TextWindow{Text: text, TextType: TextTypeCommand, Voice: v})
+	if tm.status == TaskStatusIdle {
+		tm.startNewTask()
+	}
+	logger.Info(fmt.Sprintf("%s添加指令回复: %s", tm.logPrefix(), text))
+}
+
+// takeAnnouncements 取出所有待播报的房管指令回复
+func (tm *TaskManager) takeAnnouncements() []TextWindow {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	announcements := tm.announcements
+	tm.announcements = nil
+	return announcements
+}
+
+// Mutes 返回直播间的禁言名单
+func (tm *TaskManager) Mutes() *filter.MuteList {
+	return tm.mutes
+}
+
+// Volume 返回直播间的播报音量
+func (tm *TaskManager) Volume() int {
+	tm.mutex.RLock()
+	defer tm.mutex.RUnlock()
+	if tm.shared || tm.volume == 0 {
+		return config.GetVolume()
+	}
+	return tm.volume
+}
+
+// SetVolume 修改直播间的播报音量，下一句播报开始生效
+// 全局任务管理器的音量保存到 user.json，多直播间时只修改本直播间
+func (tm *TaskManager) SetVolume(volume int) error {
+	if tm.shared {
+		return config.SetVolume(volume)
+	}
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	tm.volume = volume
+	logger.Info(fmt.Sprintf("%s播报音量已调整为 %d", tm.logPrefix(), volume))
+	return nil
+}
+
+// UseLLMReply 直播间是否使用LLM回复
+func (tm *TaskManager) UseLLMReply() bool {
+	tm.mutex.RLock()
+	defer tm.mutex.RUnlock()
+	if tm.shared || tm.useLLMReply == nil {
+		return config.GetUseLLMReplay()
+	}
+	return *tm.useLLMReply
+}
+
+// SetUseLLMReply 修改直播间是否使用LLM回复
+// 全局任务管理器的设置保存到 user.json，多直播间时只修改本直播间
+func (tm *TaskManager) SetUseLLMReply(use bool) error {
+	if tm.shared {
+		return config.SetUseLLMReplay(use)
+	}
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	tm.useLLMReply = &use
+	logger.Info(fmt.Sprintf("%sLLM回复设置为 %t", tm.logPrefix(), use))
+	return nil
+}
+
+// waitResumed 暂停时等待恢复播报
+func (tm *TaskManager) waitResumed(ctx context.Context) error {
+	tm.mutex.RLock()
+	resumed := tm.resumed
+	tm.mutex.RUnlock()
+	if resumed == nil {
+		return nil
+	}
+
+	select {
+	case <-resumed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isBatchCleared 当前取出的文本是否已被清空
+func (tm *TaskManager) isBatchCleared() bool {
+	tm.mutex.RLock()
+	defer tm.mutex.RUnlock()
+	return tm.batchCleared
+}
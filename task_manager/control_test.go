@@ -0,0 +1,26 @@
+package task_manager
+
+import "testing"
+
+func TestAnnounceQueuesTask(t *testing.T) {
+	tm := NewTaskManager(Options{Name: "test"})
+
+	tm.Announce("已跳过当前播报", nil)
+	if !tm.IsTaskRunning() {
+		t.Fatal("指令回复应开始新任务")
+	}
+	select {
+	case <-tm.NotifyChannel():
+	default:
+		t.Fatal("指令回复应通知任务处理器")
+	}
+
+	tm.AddText("普通弹幕", TextTypeNormal, nil)
+	tm.ClearQueue()
+	if got := tm.takeAnnouncements(); len(got) != 1 || got[0].Text != "已跳过当前播报" {
+		t.Fatalf("清空后的指令回复 = %v, want 1条", got)
+	}
+	if got := tm.takeAnnouncements(); len(got) != 0 {
+		t.Errorf("取出后还剩 %d 条指令回复", len(got))
+	}
+}
@@ -1,13 +1,16 @@
 package task_manager
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/CoffeeSwt/bilibili-tts-chat/config"
+	"github.com/CoffeeSwt/bilibili-tts-chat/filter"
 	"github.com/CoffeeSwt/bilibili-tts-chat/llm"
 	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+	"github.com/CoffeeSwt/bilibili-tts-chat/voice"
 )
 
 // TaskStatus 任务状态枚举
@@ -29,6 +32,7 @@ type TextWindow struct {
 	Text     string
 	TextType TextType
 	Voice    *config.Voice
+	SourceID string // 来源消息id，用于撤回，为空时不能撤回
 }
 
 // TaskManager 任务管理器
@@ -39,6 +43,34 @@ type TaskManager struct {
 	textWindow  []TextWindow  // 文本窗口
 	taskCounter int           // 任务计数器，用于生成任务ID
 	taskNotify  chan struct{} // 任务通知通道
+
+	name    string       // 直播间名称，用于日志
+	persona *llm.Persona // 助手人设，为空时使用 user.json 中的配置
+	memory  *llm.Memory  // 助手记忆
+	sink    voice.Sink   // 音频输出
+
+	mutes       *filter.MuteList // 直播间的禁言名单
+	volume      int              // 直播间的播报音量，为0时使用 user.json 中的音量
+	useLLMReply *bool            // 直播间是否使用LLM回复，为空时使用 user.json 中的设置
+	shared      bool             // 全局任务管理器，音量和LLM回复设置保存到 user.json
+
+	retracted  map[string]time.Time // 已撤回的来源消息id及撤回时间
+	playingIDs []string             // 正在播放的文本的来源消息id
+	playCancel context.CancelFunc   // 停止正在播放的音频
+
+	announcements []TextWindow // 待播报的房管指令回复，优先于窗口中的文本播报
+
+	resumed      chan struct{} // 暂停时不为空，恢复时关闭
+	batchCleared bool          // 取出的文本在播报前被清空
+}
+
+// Options 任务管理器配置，多直播间时每个直播间使用独立的任务管理器
+type Options struct {
+	Name    string       // 直播间名称，用于日志
+	Persona *llm.Persona // 助手人设，为空时使用 user.json 中的配置
+	Memory  *llm.Memory  // 助手记忆，为空时创建新的记忆
+	Sink    voice.Sink   // 音频输出，为空时在默认声卡上播放
+	Volume  int          // 播报音量 1到100，为0时使用 user.json 中的音量
 }
 
 var (
@@ -46,24 +78,74 @@ var (
 	once     sync.Once
 )
 
+// NewTaskManager 创建任务管理器
+func NewTaskManager(opts Options) *TaskManager {
+	if opts.Memory == nil {
+		opts.Memory = llm.NewMemory(0)
+	}
+	if opts.Sink == nil {
+		opts.Sink = voice.DeviceSink{}
+	}
+	return &TaskManager{
+		status:      TaskStatusIdle,
+		currentTask: nil,
+		textWindow:  make([]TextWindow, 0),
+		taskCounter: 0,
+		taskNotify:  make(chan struct{}, 1), // 缓冲通道，避免阻塞
+		name:        opts.Name,
+		persona:     opts.Persona,
+		memory:      opts.Memory,
+		sink:        opts.Sink,
+		mutes:       filter.NewMuteList(),
+		volume:      opts.Volume,
+		retracted:   make(map[string]time.Time),
+	}
+}
+
 // GetInstance 获取任务管理器单例实例
 func GetInstance() *TaskManager {
 	once.Do(func() {
-		instance = &TaskManager{
-			status:      TaskStatusIdle,
-			currentTask: nil,
-			textWindow:  make([]TextWindow, 0),
-			taskCounter: 0,
-			taskNotify:  make(chan struct{}, 1), // 缓冲通道，避免阻塞
-		}
+		instance = NewTaskManager(Options{Memory: llm.DefaultMemory()})
+		instance.shared = true
 		logger.Info("任务管理器初始化完成")
 	})
 	return instance
 }
 
+// Name 返回直播间名称
+func (tm *TaskManager) Name() string {
+	return tm.name
+}
+
+// Persona 返回助手人设
+func (tm *TaskManager) Persona() llm.Persona {
+	if tm.persona == nil {
+		return llm.DefaultPersona()
+	}
+	return *tm.persona
+}
+
+// NotifyChannel 获取任务通知通道
+func (tm *TaskManager) NotifyChannel() <-chan struct{} {
+	return tm.taskNotify
+}
+
+// logPrefix 多直播间时在日志前加上直播间名称
+func (tm *TaskManager) logPrefix() string {
+	if tm.name == "" {
+		return ""
+	}
+	return fmt.Sprintf("[%s] ", tm.name)
+}
+
 // AddText 添加文本到窗口
 // 当窗口为空时，第一个文本的添加会自动开始新任务
 func (tm *TaskManager) AddText(text string, textType TextType, voice *config.Voice) error {
+	return tm.AddTextWithSource(text, textType, voice, "")
+}
+
+// AddTextWithSource 添加文本到窗口，并记录来源消息id，来源消息被撤回时文本会被移除或停止播放
+func (tm *TaskManager) AddTextWithSource(text string, textType TextType, voice *config.Voice, sourceID string) error {
 	tm.mutex.Lock()
 	defer tm.mutex.Unlock()
 
@@ -71,15 +153,12 @@ func (tm *TaskManager) AddText(text string, textType TextType, voice *config.Voi
 		return fmt.Errorf("文本内容不能为空")
 	}
 
+	// 先添加到助手记忆中，来源消息被撤回时一并移除
+	tm.memory.AddWithSource(text, sourceID)
+
 	// 检查是否需要开始新任务
 	if tm.status == TaskStatusIdle && len(tm.textWindow) == 0 {
 		tm.startNewTask()
-		// 发送任务通知
-		select {
-		case tm.taskNotify <- struct{}{}:
-		default:
-			// 通道已满，忽略
-		}
 	}
 
 	// 添加文本到窗口
@@ -87,6 +166,7 @@ func (tm *TaskManager) AddText(text string, textType TextType, voice *config.Voi
 		Text:     text,
 		TextType: textType,
 		Voice:    voice,
+		SourceID: sourceID,
 	})
 
 	// 如果有当前任务，也添加到任务记录中
@@ -95,14 +175,15 @@ func (tm *TaskManager) AddText(text string, textType TextType, voice *config.Voi
 			Text:     text,
 			TextType: textType,
 			Voice:    voice,
+			SourceID: sourceID,
 		})
 	}
 
-	logger.Info(fmt.Sprintf("添加文本到窗口: %s (窗口大小: %d)", text, len(tm.textWindow)))
+	logger.Info(fmt.Sprintf("%s添加文本到窗口: %s (窗口大小: %d)", tm.logPrefix(), text, len(tm.textWindow)))
 	return nil
 }
 
-// startNewTask 开始新任务（内部方法，调用前需要加锁）
+// startNewTask 开始新任务并通知任务处理器（内部方法，调用前需要加锁）
 func (tm *TaskManager) startNewTask() {
 	tm.taskCounter++
 	taskID := fmt.Sprintf("task_%d_%d", tm.taskCounter, time.Now().Unix())
@@ -114,7 +195,14 @@ func (tm *TaskManager) startNewTask() {
 	}
 	tm.status = TaskStatusRunning
 
-	logger.Info(fmt.Sprintf("开始新任务: %s", taskID))
+	// 发送任务通知
+	select {
+	case tm.taskNotify <- struct{}{}:
+	default:
+		// 通道已满，忽略
+	}
+
+	logger.Info(fmt.Sprintf("%s开始新任务: %s", tm.logPrefix(), taskID))
 }
 
 // CompleteTask 完成当前任务并返回所有文本信息
@@ -143,6 +231,7 @@ func (tm *TaskManager) CompleteTask() []TextWindow {
 	tm.textWindow = tm.textWindow[:0] // 清空slice但保留容量
 	tm.status = TaskStatusIdle
 	tm.currentTask = nil
+	tm.batchCleared = false
 
 	logger.Info("任务窗口已清空，准备接收下一个任务")
 	return texts
@@ -214,6 +303,7 @@ func (tm *TaskManager) ForceCompleteTask() []TextWindow {
 	tm.textWindow = tm.textWindow[:0]
 	tm.status = TaskStatusIdle
 	tm.currentTask = nil
+	tm.batchCleared = false
 
 	return texts
 }
@@ -262,8 +352,6 @@ const (
 
 // AddText 添加文本到全局任务管理器
 func AddText(text string, textType TextType, voice *config.Voice) error {
-	// 先添加到缓存中
-	llm.AddCacheEventData(text)
 	return GetInstance().AddText(text, textType, voice)
 }
 
@@ -294,7 +382,11 @@ func GetWindowSize() int {
 
 // ClearTasks 清空所有任务和窗口（用于重启应用）
 func ClearTasks() {
-	tm := GetInstance()
+	GetInstance().ClearTasks()
+}
+
+// ClearTasks 清空所有任务和窗口（用于重启应用）
+func (tm *TaskManager) ClearTasks() {
 	tm.mutex.Lock()
 	defer tm.mutex.Unlock()
 
@@ -308,7 +400,7 @@ func ClearTasks() {
 	default:
 	}
 
-	logger.Info("任务管理器状态已重置")
+	logger.Info(fmt.Sprintf("%s任务管理器状态已重置", tm.logPrefix()))
 }
 
 // GetStats 获取统计信息
@@ -318,5 +410,5 @@ func GetStats() map[string]interface{} {
 
 // GetTaskNotifyChannel 获取任务通知通道
 func GetTaskNotifyChannel() <-chan struct{} {
-	return GetInstance().taskNotify
+	return GetInstance().NotifyChannel()
 }
@@ -0,0 +1,101 @@
+package task_manager
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+)
+
+// retractKeep 已撤回的来源消息id保留的时间，在此期间取出但还没播放的文本也会被跳过
+const retractKeep = 10 * time.Minute
+
+// Retract 撤回来源消息，移除窗口和助手记忆中对应的文本，正在播放时立即停止
+// 返回移除的文本数和是否停止了正在播放的音频
+func (tm *TaskManager) Retract(sourceIDs ...string) (removed int, interrupted bool) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	now := time.Now()
+	for id, at := range tm.retracted {
+		if now.Sub(at) > retractKeep {
+			delete(tm.retracted, id)
+		}
+	}
+	for _, id := range sourceIDs {
+		if id != "" {
+			tm.retracted[id] = now
+		}
+	}
+
+	kept := tm.textWindow[:0]
+	for _, text := range tm.textWindow {
+		if text.SourceID != "" && slices.Contains(sourceIDs, text.SourceID) {
+			removed++
+			logger.Info(fmt.Sprintf("%s撤回待播报文本: %s", tm.logPrefix(), text.Text))
+			continue
+		}
+		kept = append(kept, text)
+	}
+	tm.textWindow = kept
+	if n := tm.memory.Remove(sourceIDs...); n > 0 {
+		logger.Info(fmt.Sprintf("%s从助手记忆中移除 %d 条已撤回的文本", tm.logPrefix(), n))
+	}
+	if tm.currentTask != nil {
+		tm.currentTask.Texts = slices.DeleteFunc(tm.currentTask.Texts, func(text TextWindow) bool {
+			return text.SourceID != "" && slices.Contains(sourceIDs, text.SourceID)
+		})
+	}
+
+	if tm.playCancel != nil && slices.ContainsFunc(tm.playingIDs, func(id string) bool {
+		return id != "" && slices.Contains(sourceIDs, id)
+	}) {
+		tm.playCancel()
+		interrupted = true
+		logger.Info(fmt.Sprintf("%s撤回正在播放的文本，已停止播放", tm.logPrefix()))
+	}
+	return removed, interrupted
+}
+
+// isRetracted 来源消息是否已被撤回
+func (tm *TaskManager) isRetracted(sourceID string) bool {
+	if sourceID == "" {
+		return false
+	}
+	tm.mutex.RLock()
+	defer tm.mutex.RUnlock()
+	_, ok := tm.retracted[sourceID]
+	return ok
+}
+
+// filterRetracted 去掉已撤回的文本
+func (tm *TaskManager) filterRetracted(texts []TextWindow) []TextWindow {
+	return slices.DeleteFunc(slices.Clone(texts), func(text TextWindow) bool {
+		if tm.isRetracted(text.SourceID) {
+			logger.Info(fmt.Sprintf("%s跳过已撤回的文本: %s", tm.logPrefix(), text.Text))
+			return true
+		}
+		return false
+	})
+}
+
+// setPlaying 记录正在播放的文本的来源消息id，cancel 用于撤回时停止播放
+func (tm *TaskManager) setPlaying(sourceIDs []string, cancel context.CancelFunc) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	tm.playingIDs = sourceIDs
+	tm.playCancel = cancel
+}
+
+// sourceIDs 返回文本的来源消息id
+func sourceIDs(texts []TextWindow) []string {
+	var ids []string
+	for _, text := range texts {
+		if text.SourceID != "" {
+			ids = append(ids, text.SourceID)
+		}
+	}
+	return ids
+}
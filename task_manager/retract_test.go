@@ -0,0 +1,45 @@
+package task_manager
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/llm"
+)
+
+func TestRetractRemovesMemory(t *testing.T) {
+	memory := llm.NewMemory(10)
+	tm := NewTaskManager(Options{Name: "test", Memory: memory})
+
+	tm.AddTextWithSource("醒目留言一", TextTypeNormal, nil, "sc-1")
+	tm.AddTextWithSource("醒目留言二", TextTypeNormal, nil, "sc-2")
+	tm.AddText("普通弹幕", TextTypeNormal, nil)
+
+	removed, _ := tm.Retract("sc-1")
+	if removed != 1 {
+		t.Fatalf("removed = %d, want 1", removed)
+	}
+
+	got := memory.Get()
+	want := []string{"醒目留言二", "普通弹幕"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("记忆 = %v, want %v", got, want)
+	}
+}
+
+func TestRetractRemovesLLMReply(t *testing.T) {
+	memory := llm.NewMemory(10)
+	tm := NewTaskManager(Options{Name: "test", Memory: memory})
+
+	// LLM回复记录了它回应的所有文本的来源
+	memory.AddWithSource("谢谢两位的醒目留言", "sc-1", "sc-2")
+	memory.Add("欢迎来到直播间")
+
+	tm.Retract("sc-2")
+
+	got := memory.Get()
+	want := []string{"欢迎来到直播间"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("记忆 = %v, want %v", got, want)
+	}
+}
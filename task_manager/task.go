@@ -2,6 +2,7 @@ package task_manager
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"strings"
@@ -13,16 +14,26 @@ import (
 	"github.com/CoffeeSwt/bilibili-tts-chat/voice"
 )
 
-// PlayEventTasks 处理事件任务：从task_manager获取文本、调用LLM、生成语音、播报
+// PlayEventTasks 处理全局任务管理器中的事件任务
 func PlayEventTasks(ctx context.Context) {
+	GetInstance().PlayEventTasks(ctx)
+}
+
+// PlayEventTasks 处理事件任务：从task_manager获取文本、调用LLM、生成语音、播报
+func (tm *TaskManager) PlayEventTasks(ctx context.Context) {
 	// 检查是否有正在运行的任务
-	if !IsTaskRunning() {
+	if !tm.IsTaskRunning() {
 		logger.Warn("PlayEventTasks: 没有正在运行的任务")
 		return
 	}
 
 	// 从task_manager获取文本并完成任务
-	texts := CompleteTask()
+	texts := tm.CompleteTask()
+
+	// 先播报房管指令的回复
+	if err := tm.UseAnnounceTask(ctx, tm.takeAnnouncements()); err != nil {
+		logger.Error(fmt.Sprintf("PlayEventTasks: UseAnnounceTask 失败: %v", err))
+	}
 
 	var llmTexts []TextWindow
 	var commandTexts []TextWindow
@@ -43,20 +54,20 @@ func PlayEventTasks(ctx context.Context) {
 	}
 	// 处理LLM文本
 	if len(llmTexts) > 0 {
-		if err := UseLLMTask(ctx, llmTexts); err != nil {
+		if err := tm.UseLLMTask(ctx, llmTexts); err != nil {
 			logger.Error(fmt.Sprintf("PlayEventTasks: UseLLMTask 失败: %v", err))
 		}
 	}
 
 	// 处理命令文本
 	if len(commandTexts) > 0 {
-		if err := UseCommandTask(ctx, commandTexts); err != nil {
+		if err := tm.UseCommandTask(ctx, commandTexts); err != nil {
 			logger.Error(fmt.Sprintf("PlayEventTasks: UseCommandTask 失败: %v", err))
 		}
 	}
 
 	if len(noLLMReplyTexts) > 0 {
-		if err := UseNoLLMReplyTask(ctx, noLLMReplyTexts); err != nil {
+		if err := tm.UseNoLLMReplyTask(ctx, noLLMReplyTexts); err != nil {
 			logger.Error(fmt.Sprintf("PlayEventTasks: UseNoLLMReplyTask 失败: %v", err))
 		}
 	}
@@ -138,32 +149,60 @@ func generateSpeech(text string, voice *config.Voice) ([]byte, error) {
 	return ttsResult.AudioData, nil
 }
 
-// PlayAudioAndWait 播放音频并等待完成
+// PlayAudioAndWait 在默认声卡上播放音频并等待完成
 func PlayAudioAndWait(ctx context.Context, audioData []byte) error {
-	// 使用带完成信号的播放接口
-	completionChan, err := voice.PlayAudioWithCompletion(audioData) // 音量设置为80%
-	if err != nil {
-		return fmt.Errorf("启动音频播放失败: %v", err)
+	if err := (voice.DeviceSink{}).Play(ctx, audioData); err != nil {
+		return err
 	}
+	logger.Info("playAudioAndWait: 音频播放完成")
+	return nil
+}
 
-	// 等待播放完成或上下文取消
-	select {
-	case <-ctx.Done():
-		return fmt.Errorf("音频播放被取消")
-	case <-completionChan:
-		// 播放完成
-		logger.Info("playAudioAndWait: 音频播放完成")
-		return nil
+// playAudio 通过直播间的音频输出播放音频并等待完成，暂停时等待恢复后再播放
+// sourceIDs 为音频对应文本的来源消息id，来源消息被撤回、语音被跳过或者文本被清空时返回 voice.ErrPlaybackInterrupted
+func (tm *TaskManager) playAudio(ctx context.Context, audioData []byte, sourceIDs ...string) error {
+	if err := tm.waitResumed(ctx); err != nil {
+		return err
+	}
+	if tm.isBatchCleared() {
+		return voice.ErrPlaybackInterrupted
 	}
+
+	playCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tm.setPlaying(sourceIDs, cancel)
+	defer tm.setPlaying(nil, nil)
+
+	if err := tm.sink.Play(voice.WithVolume(playCtx, tm.Volume()), audioData); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if playCtx.Err() != nil {
+			return voice.ErrPlaybackInterrupted
+		}
+		return err
+	}
+	logger.Info(fmt.Sprintf("%splayAudio: 音频播放完成", tm.logPrefix()))
+	return nil
 }
 
 func UseLLMTask(ctx context.Context, texts []TextWindow) error {
+	return GetInstance().UseLLMTask(ctx, texts)
+}
+
+func (tm *TaskManager) UseLLMTask(ctx context.Context, texts []TextWindow) error {
 	// 参数验证
 	if len(texts) == 0 {
 		logger.Warn("PlayEventTasks: 从任务管理器获取的文本列表为空")
 		return nil
 	}
 
+	// 去掉排队期间被撤回的文本
+	texts = tm.filterRetracted(texts)
+	if len(texts) == 0 {
+		return nil
+	}
+
 	logger.Info("PlayEventTasks: 开始处理任务", "texts_count", len(texts))
 
 	// 2. 生成提示词并调用大模型
@@ -171,7 +210,7 @@ func UseLLMTask(ctx context.Context, texts []TextWindow) error {
 	for _, text := range texts {
 		textContents = append(textContents, text.Text)
 	}
-	prompt := llm.GeneratePrompt(textContents)
+	prompt := llm.GeneratePromptForPersona(tm.Persona(), tm.memory.Get(), textContents)
 	logger.Info("PlayEventTasks: 提示词生成完成", "prompt_length", len(prompt))
 
 	// 检查上下文是否已取消
@@ -193,9 +232,6 @@ func UseLLMTask(ctx context.Context, texts []TextWindow) error {
 		logger.Warn("PlayEventTasks: LLM返回空响应")
 		return nil
 	}
-	// 4. 缓存LLM响应
-	llm.AddCacheEventData(llmResponse)
-
 	logger.Info(fmt.Sprintf("🤖 [LLM回复] %s", llmResponse))
 	logger.Info("PlayEventTasks: LLM响应获取完成", "response_length", len(llmResponse))
 
@@ -225,8 +261,21 @@ func UseLLMTask(ctx context.Context, texts []TextWindow) error {
 	default:
 	}
 
+	// 生成期间有文本被撤回时不再播报
+	if len(tm.filterRetracted(texts)) < len(texts) {
+		logger.Info("PlayEventTasks: 部分文本已被撤回，取消播报")
+		return nil
+	}
+
+	// 4. 缓存LLM响应，回复对应的任一文本被撤回时一并移除
+	tm.memory.AddWithSource(llmResponse, sourceIDs(texts)...)
+
 	// 6. 播报语音并等待播报完成
-	err = PlayAudioAndWait(ctx, audioData)
+	err = tm.playAudio(ctx, audioData, sourceIDs(texts)...)
+	if errors.Is(err, voice.ErrPlaybackInterrupted) {
+		logger.Info("PlayEventTasks: 播报已被撤回、跳过或清空")
+		return nil
+	}
 	if err != nil {
 		logger.Error("PlayEventTasks: 音频播放失败", "error", err)
 		return nil
@@ -237,14 +286,26 @@ func UseLLMTask(ctx context.Context, texts []TextWindow) error {
 }
 
 func UseCommandTask(ctx context.Context, texts []TextWindow) error {
-	for _, text := range texts {
+	return GetInstance().UseCommandTask(ctx, texts)
+}
+
+func (tm *TaskManager) UseCommandTask(ctx context.Context, texts []TextWindow) error {
+	for _, text := range tm.filterRetracted(texts) {
 		audioData, err := generateSpeech(text.Text, text.Voice)
 		if err != nil {
 			logger.Error("PlayEventTasks: 语音生成失败", "error", err)
 			return nil
 		}
 		logger.Info("PlayEventTasks: 语音生成完成", "audio_size", len(audioData))
-		err = PlayAudioAndWait(ctx, audioData)
+		if tm.isRetracted(text.SourceID) {
+			logger.Info("PlayEventTasks: 文本已被撤回，跳过播报", "text", text.Text)
+			continue
+		}
+		err = tm.playAudio(ctx, audioData, text.SourceID)
+		if errors.Is(err, voice.ErrPlaybackInterrupted) {
+			logger.Info("PlayEventTasks: 播报已被撤回、跳过或清空", "text", text.Text)
+			continue
+		}
 		if err != nil {
 			logger.Error("PlayEventTasks: 音频播放失败", "error", err)
 			return nil
@@ -254,15 +315,47 @@ func UseCommandTask(ctx context.Context, texts []TextWindow) error {
 	return nil
 }
 
-func UseNoLLMReplyTask(ctx context.Context, texts []TextWindow) error {
+// UseAnnounceTask 播报房管指令的回复，回复没有来源消息，不会被撤回或清空
+func (tm *TaskManager) UseAnnounceTask(ctx context.Context, texts []TextWindow) error {
 	for _, text := range texts {
+		audioData, err := generateSpeech(text.Text, text.Voice)
+		if err != nil {
+			logger.Error("PlayEventTasks: 语音生成失败", "error", err)
+			continue
+		}
+		err = tm.playAudio(ctx, audioData)
+		if errors.Is(err, voice.ErrPlaybackInterrupted) {
+			logger.Info("PlayEventTasks: 指令回复已被跳过", "text", text.Text)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func UseNoLLMReplyTask(ctx context.Context, texts []TextWindow) error {
+	return GetInstance().UseNoLLMReplyTask(ctx, texts)
+}
+
+func (tm *TaskManager) UseNoLLMReplyTask(ctx context.Context, texts []TextWindow) error {
+	for _, text := range tm.filterRetracted(texts) {
 		audioData, err := generateSpeech(text.Text, text.Voice)
 		if err != nil {
 			logger.Error("PlayEventTasks: 语音生成失败", "error", err)
 			return nil
 		}
 		logger.Info("PlayEventTasks: 语音生成完成", "audio_size", len(audioData))
-		err = PlayAudioAndWait(ctx, audioData)
+		if tm.isRetracted(text.SourceID) {
+			logger.Info("PlayEventTasks: 文本已被撤回，跳过播报", "text", text.Text)
+			continue
+		}
+		err = tm.playAudio(ctx, audioData, text.SourceID)
+		if errors.Is(err, voice.ErrPlaybackInterrupted) {
+			logger.Info("PlayEventTasks: 播报已被撤回、跳过或清空", "text", text.Text)
+			continue
+		}
 		if err != nil {
 			logger.Error("PlayEventTasks: 音频播放失败", "error", err)
 			return nil
@@ -0,0 +1,41 @@
+package task_manager
+
+import "testing"
+
+func TestVolumePerRoom(t *testing.T) {
+	a := NewTaskManager(Options{Name: "a", Volume: 30})
+	b := NewTaskManager(Options{Name: "b"})
+
+	if got := a.Volume(); got != 30 {
+		t.Fatalf("a.Volume() = %d, want 30", got)
+	}
+	def := b.Volume()
+	if err := b.SetVolume(80); err != nil {
+		t.Fatalf("SetVolume: %v", err)
+	}
+	if got := b.Volume(); got != 80 {
+		t.Errorf("b.Volume() = %d, want 80", got)
+	}
+	if got := a.Volume(); got != 30 {
+		t.Errorf("修改b后 a.Volume() = %d, want 30", got)
+	}
+	if got := NewTaskManager(Options{}).Volume(); got != def {
+		t.Errorf("未设置音量时 Volume() = %d, want %d", got, def)
+	}
+}
+
+func TestUseLLMReplyPerRoom(t *testing.T) {
+	a := NewTaskManager(Options{Name: "a"})
+	b := NewTaskManager(Options{Name: "b"})
+
+	def := a.UseLLMReply()
+	if err := b.SetUseLLMReply(!def); err != nil {
+		t.Fatalf("SetUseLLMReply: %v", err)
+	}
+	if got := b.UseLLMReply(); got != !def {
+		t.Errorf("b.UseLLMReply() = %t, want %t", got, !def)
+	}
+	if got := a.UseLLMReply(); got != def {
+		t.Errorf("修改b后 a.UseLLMReply() = %t, want %t", got, def)
+	}
+}
@@ -40,6 +40,7 @@ type AudioParams struct {
 	SampleRate      int    `json:"sample_rate"`
 	EnableTimestamp bool   `json:"enable_timestamp"`
 	SpeechRate      int    `json:"speech_rate"`
+	LoudnessRate    int    `json:"loudness_rate,omitempty"`
 }
 
 // ReqParams 请求参数
@@ -116,7 +117,8 @@ func GenerateSpeech(text string, voice *config.Voice) (*TTSResult, error) {
 				Format:          "mp3",
 				SampleRate:      24000,
 				EnableTimestamp: true,
-				SpeechRate:      config.GetSpeechRate(),
+				SpeechRate:      config.ClampSpeechRate(config.GetSpeechRate() + voice.SpeechRateOffset),
+				LoudnessRate:    config.ClampLoudnessRate(voice.LoudnessRate),
 			},
 			Additions: `{"explicit_language": "zh","disable_markdown_filter":true, "enable_timestamp":true}`,
 		},
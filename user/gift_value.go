@@ -0,0 +1,119 @@
+package user
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/logger"
+)
+
+// giftSaveDelay 送礼价值变化后延迟保存的时间，期间的多次送礼合并为一次写入
+const giftSaveDelay = 10 * time.Second
+
+// pendingSave 等待中的延迟保存，为空时没有等待保存的修改，需要在锁保护下访问
+var pendingSave *time.Timer
+
+// giftKey 累计送礼价值的键，优先使用 open_id，改名后不会丢失
+func giftKey(openID, userName string) string {
+	if openID != "" {
+		return openID
+	}
+	return userName
+}
+
+// AddGiftValue 累加用户送出的付费礼物价值，value 为礼物价值，1000 = 1元
+// 按 open_id 记录，不随不活跃用户清理；延迟保存，连续送礼只写入一次
+func AddGiftValue(openID, userName string, value int) error {
+	if value <= 0 {
+		return nil
+	}
+	key := giftKey(openID, userName)
+	if key == "" {
+		return fmt.Errorf("用户标识为空")
+	}
+	loadUserVoices()
+
+	voiceMutex.Lock()
+	if userVoices.GiftValues == nil {
+		userVoices.GiftValues = make(map[string]int)
+	}
+	// 旧版本按昵称记录的价值在第一次带 open_id 送礼时合并
+	if key != userName {
+		if legacy, ok := userVoices.GiftValues[userName]; ok {
+			userVoices.GiftValues[key] += legacy
+			delete(userVoices.GiftValues, userName)
+		}
+	}
+	userVoices.GiftValues[key] += value
+	total := userVoices.GiftValues[key]
+	scheduleSaveInternal()
+	voiceMutex.Unlock()
+
+	logger.Debug(fmt.Sprintf("[AddGiftValue] 用户 %s 累计送礼价值: %d", userName, total))
+	return nil
+}
+
+// GetGiftValue 获取用户累计送出的付费礼物价值，1000 = 1元
+// 没有按 open_id 的记录时使用旧版本按昵称记录的价值
+func GetGiftValue(openID, userName string) int {
+	loadUserVoices()
+
+	voiceMutex.RLock()
+	defer voiceMutex.RUnlock()
+
+	if value, ok := userVoices.GiftValues[giftKey(openID, userName)]; ok {
+		return value
+	}
+	return userVoices.GiftValues[userName]
+}
+
+// migrateGiftValuesInternal 把旧版本记录在音色信息中的送礼价值移到 GiftValues，需要在锁保护下调用
+func migrateGiftValuesInternal() {
+	for userName, userInfo := range userVoices.UserVoices {
+		if userInfo.GiftValue <= 0 {
+			continue
+		}
+		if userVoices.GiftValues == nil {
+			userVoices.GiftValues = make(map[string]int)
+		}
+		userVoices.GiftValues[userName] += userInfo.GiftValue
+		userInfo.GiftValue = 0
+		userVoices.UserVoices[userName] = userInfo
+	}
+}
+
+// scheduleSaveInternal 延迟保存配置，已有等待中的保存时不重复安排，需要在锁保护下调用
+func scheduleSaveInternal() {
+	if pendingSave != nil {
+		return
+	}
+	pendingSave = time.AfterFunc(giftSaveDelay, func() {
+		voiceMutex.Lock()
+		defer voiceMutex.Unlock()
+
+		pendingSave = nil
+		if err := saveUserVoicesInternal(); err != nil {
+			logger.Error(fmt.Sprintf("[AddGiftValue] 保存用户送礼价值失败: %v", err))
+		}
+	})
+}
+
+// stopPendingSaveInternal 取消等待中的延迟保存，调用方随后立即保存，需要在锁保护下调用
+func stopPendingSaveInternal() {
+	if pendingSave != nil {
+		pendingSave.Stop()
+		pendingSave = nil
+	}
+}
+
+// FlushGiftValues 立即保存等待延迟写入的送礼价值，没有等待保存的修改时不写入
+func FlushGiftValues() error {
+	voiceMutex.Lock()
+	defer voiceMutex.Unlock()
+
+	if pendingSave == nil {
+		return nil
+	}
+	stopPendingSaveInternal()
+	return saveUserVoicesInternal()
+}
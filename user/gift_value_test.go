@@ -0,0 +1,75 @@
+package user
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// useTempConfig 让配置写入临时目录，不读取项目中的 user_voices.yaml
+func useTempConfig(t *testing.T) {
+	t.Helper()
+	once.Do(func() {})
+	configPath = filepath.Join(t.TempDir(), "user_voices.yaml")
+	userVoices = UserVoice{UserVoices: make(UserVoiceMap)}
+}
+
+func TestGiftValueSurvivesCleanupAndRename(t *testing.T) {
+	useTempConfig(t)
+
+	if err := AddGiftValue("open-1", "老板", 50000); err != nil {
+		t.Fatalf("AddGiftValue: %v", err)
+	}
+	if err := AddGiftValue("open-1", "老板", 50000); err != nil {
+		t.Fatalf("AddGiftValue: %v", err)
+	}
+
+	// 很久没有活跃，音色记录被清理
+	userVoices.UserVoices["老板"] = UserVoiceInfo{VoiceType: "v", LastActiveTime: time.Now().AddDate(-1, 0, 0)}
+	if n := CleanupInactiveUsers(); n != 1 {
+		t.Fatalf("清理了 %d 个用户, want 1", n)
+	}
+
+	// 改名后仍按 open_id 找到
+	if got := GetGiftValue("open-1", "新昵称"); got != 100000 {
+		t.Errorf("GetGiftValue = %d, want 100000", got)
+	}
+}
+
+func TestGiftValueDelayedSave(t *testing.T) {
+	useTempConfig(t)
+
+	if err := AddGiftValue("open-2", "观众", 1000); err != nil {
+		t.Fatalf("AddGiftValue: %v", err)
+	}
+	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
+		t.Fatalf("送礼后不应立即写入配置文件: %v", err)
+	}
+
+	if err := FlushGiftValues(); err != nil {
+		t.Fatalf("FlushGiftValues: %v", err)
+	}
+	if _, err := os.Stat(configPath); err != nil {
+		t.Fatalf("FlushGiftValues 后应写入配置文件: %v", err)
+	}
+}
+
+func TestLegacyGiftValueMigration(t *testing.T) {
+	useTempConfig(t)
+	userVoices.UserVoices["老观众"] = UserVoiceInfo{VoiceType: "v", GiftValue: 3000}
+	voiceMutex.Lock()
+	migrateGiftValuesInternal()
+	voiceMutex.Unlock()
+
+	if got := GetGiftValue("open-3", "老观众"); got != 3000 {
+		t.Fatalf("迁移后 GetGiftValue = %d, want 3000", got)
+	}
+	if err := AddGiftValue("open-3", "老观众", 1000); err != nil {
+		t.Fatalf("AddGiftValue: %v", err)
+	}
+	if got := GetGiftValue("open-3", "改名了"); got != 4000 {
+		t.Errorf("合并后 GetGiftValue = %d, want 4000", got)
+	}
+	stopPendingSaveInternal()
+}
@@ -21,14 +21,19 @@ var (
 	configPath string       // 配置文件的绝对路径
 )
 
-// UserVoiceInfo 用户音色信息，包含音色类型和最后活跃时间
+// UserVoiceInfo 用户音色信息，包含音色类型、语速和音量偏移和最后活跃时间
 type UserVoiceInfo struct {
 	VoiceType      string    `yaml:"voice_type"`
+	SpeechRate     int       `yaml:"speech_rate,omitempty"` // 语速偏移，叠加在全局语速上，0为正常语速
+	Volume         int       `yaml:"volume,omitempty"`      // 音量偏移，在全局播放音量的基础上调整，0为正常音量
+	GiftValue      int       `yaml:"gift_value,omitempty"`  // 旧版本按昵称记录的累计送礼价值，加载时迁移到 gift_values
 	LastActiveTime time.Time `yaml:"last_active_time"`
 }
 
 type UserVoice struct {
 	UserVoices UserVoiceMap `yaml:"user_voices"`
+	// GiftValues open_id（没有时为昵称） -> 累计送出的付费礼物价值，不随不活跃用户清理
+	GiftValues map[string]int `yaml:"gift_values,omitempty"`
 }
 
 type UserVoiceMap map[string]UserVoiceInfo
@@ -70,7 +75,18 @@ func GetUserVoice(userName string) *config.Voice {
 	userVoices.UserVoices[userName] = userInfo
 	voiceMutex.Unlock()
 
-	return config.GetVoiceByType(userInfo.VoiceType)
+	return withUserSettings(config.GetVoiceByType(userInfo.VoiceType), userInfo)
+}
+
+// withUserSettings 返回带用户语速和音量偏移的音色副本，不修改音色列表中的音色
+func withUserSettings(v *config.Voice, userInfo UserVoiceInfo) *config.Voice {
+	if v == nil || (userInfo.SpeechRate == 0 && userInfo.Volume == 0) {
+		return v
+	}
+	voice := *v
+	voice.SpeechRateOffset = userInfo.SpeechRate
+	voice.LoudnessRate = userInfo.Volume
+	return &voice
 }
 
 // loadUserVoices 加载用户音色配置，只执行一次
@@ -126,6 +142,7 @@ func loadUserVoices() {
 		if userVoices.UserVoices == nil {
 			userVoices.UserVoices = make(UserVoiceMap)
 		}
+		migrateGiftValuesInternal()
 
 		logger.Info(fmt.Sprintf("[loadUserVoices] 成功加载 %d 个用户音色配置", len(userVoices.UserVoices)))
 
@@ -201,12 +218,13 @@ func saveUserVoicesInternal() error {
 	return nil
 }
 
-// SaveUserVoices 手动保存用户音色配置（公开接口）
+// SaveUserVoices 手动保存用户音色配置（公开接口），包括还在等待保存的送礼价值
 func SaveUserVoices() error {
 	loadUserVoices()
 	voiceMutex.Lock()
 	defer voiceMutex.Unlock()
 
+	stopPendingSaveInternal()
 	return saveUserVoicesInternal()
 }
 
@@ -223,6 +241,8 @@ func SetUserVoice(userName, voiceType string) error {
 	voiceMutex.Lock()
 	userVoices.UserVoices[userName] = UserVoiceInfo{
 		VoiceType:      voice.VoiceType,
+		SpeechRate:     userVoices.UserVoices[userName].SpeechRate, // 切换音色时保留语速和音量
+		Volume:         userVoices.UserVoices[userName].Volume,
 		LastActiveTime: time.Now(),
 	}
 
@@ -238,6 +258,98 @@ func SetUserVoice(userName, voiceType string) error {
 	return nil
 }
 
+// SetUserSpeechRate 设置用户的语速偏移并保存，rate 为0时恢复正常语速
+// 偏移叠加在全局语速上，最终语速限制在 [config.MinSpeechRate, config.MaxSpeechRate] 内
+func SetUserSpeechRate(userName string, rate int) error {
+	loadUserVoices()
+
+	if rate < config.MinSpeechRate || rate > config.MaxSpeechRate {
+		return fmt.Errorf("语速 %d 超出范围 [%d, %d]", rate, config.MinSpeechRate, config.MaxSpeechRate)
+	}
+
+	voiceMutex.Lock()
+	userInfo, err := userInfoInternal(userName)
+	if err != nil {
+		voiceMutex.Unlock()
+		return err
+	}
+	userInfo.SpeechRate = rate
+	userInfo.LastActiveTime = time.Now()
+	userVoices.UserVoices[userName] = userInfo
+
+	if err := saveUserVoicesInternal(); err != nil {
+		voiceMutex.Unlock()
+		logger.Error(fmt.Sprintf("[SetUserSpeechRate] 保存用户语速失败: %v", err))
+		return fmt.Errorf("保存配置失败: %v", err)
+	}
+	voiceMutex.Unlock()
+
+	logger.Info(fmt.Sprintf("[SetUserSpeechRate] 用户 %s 语速偏移已设置为 %d 并保存", userName, rate))
+	return nil
+}
+
+// SetUserVolume 设置用户的音量偏移并保存，volume 为0时恢复正常音量
+// 偏移作为TTS的 loudness_rate 生成音频，范围为 [config.MinLoudnessRate, config.MaxLoudnessRate]
+func SetUserVolume(userName string, volume int) error {
+	loadUserVoices()
+
+	if volume < config.MinLoudnessRate || volume > config.MaxLoudnessRate {
+		return fmt.Errorf("音量 %d 超出范围 [%d, %d]", volume, config.MinLoudnessRate, config.MaxLoudnessRate)
+	}
+
+	voiceMutex.Lock()
+	userInfo, err := userInfoInternal(userName)
+	if err != nil {
+		voiceMutex.Unlock()
+		return err
+	}
+	userInfo.Volume = volume
+	userInfo.LastActiveTime = time.Now()
+	userVoices.UserVoices[userName] = userInfo
+
+	if err := saveUserVoicesInternal(); err != nil {
+		voiceMutex.Unlock()
+		logger.Error(fmt.Sprintf("[SetUserVolume] 保存用户音量失败: %v", err))
+		return fmt.Errorf("保存配置失败: %v", err)
+	}
+	voiceMutex.Unlock()
+
+	logger.Info(fmt.Sprintf("[SetUserVolume] 用户 %s 音量偏移已设置为 %d 并保存", userName, volume))
+	return nil
+}
+
+// userInfoInternal 获取用户信息，还没有音色的用户分配随机音色，需要在锁保护下调用
+func userInfoInternal(userName string) (UserVoiceInfo, error) {
+	if userInfo, exists := userVoices.UserVoices[userName]; exists {
+		return userInfo, nil
+	}
+	v := config.GetRandomVoice()
+	if v == nil {
+		return UserVoiceInfo{}, fmt.Errorf("无法获取随机音色")
+	}
+	return UserVoiceInfo{VoiceType: v.VoiceType}, nil
+}
+
+// GetUserSpeechRate 获取用户的语速偏移，没有设置时返回0
+func GetUserSpeechRate(userName string) int {
+	loadUserVoices()
+
+	voiceMutex.RLock()
+	defer voiceMutex.RUnlock()
+
+	return userVoices.UserVoices[userName].SpeechRate
+}
+
+// GetUserVolume 获取用户的音量偏移，没有设置时返回0
+func GetUserVolume(userName string) int {
+	loadUserVoices()
+
+	voiceMutex.RLock()
+	defer voiceMutex.RUnlock()
+
+	return userVoices.UserVoices[userName].Volume
+}
+
 // GetAllUserVoices 获取所有用户音色配置的副本
 func GetAllUserVoices() UserVoiceMap {
 	loadUserVoices()
@@ -263,6 +375,18 @@ func GetUserCount() int {
 	return len(userVoices.UserVoices)
 }
 
+// GetLastActiveTime 获取用户上次活跃的时间，用户不存在时返回false
+// GetUserVoice 会刷新活跃时间，需要在调用它之前获取
+func GetLastActiveTime(userName string) (time.Time, bool) {
+	loadUserVoices()
+
+	voiceMutex.RLock()
+	defer voiceMutex.RUnlock()
+
+	userInfo, exists := userVoices.UserVoices[userName]
+	return userInfo.LastActiveTime, exists
+}
+
 // UpdateUserActivity 更新用户的最后活跃时间（供外部调用）
 func UpdateUserActivity(userName string) {
 	loadUserVoices()
@@ -3,6 +3,7 @@ package voice
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -18,9 +19,13 @@ type AudioTask struct {
 	AudioData  []byte
 	Volume     int
 	Done       chan error
-	Completion chan struct{} // 播放完成信号，可选
+	Completion chan struct{}   // 播放完成信号，可选
+	Ctx        context.Context // 取消后立即停止播放，可选
 }
 
+// ErrPlaybackInterrupted 播放被取消时返回的错误
+var ErrPlaybackInterrupted = errors.New("音频播放被中断")
+
 // AudioEngine 音频播放引擎
 type AudioEngine struct {
 	contexts  map[string]*oto.Context // 支持多个采样率的上下文
@@ -109,7 +114,17 @@ func (e *AudioEngine) processQueue() {
 			if task == nil {
 				continue
 			}
-			err := e.playAudioInternal(task.AudioData, task.Volume)
+			taskCtx := task.Ctx
+			if taskCtx == nil {
+				taskCtx = context.Background()
+			}
+			var err error
+			if taskCtx.Err() != nil {
+				// 排队期间已被取消，不再播放
+				err = ErrPlaybackInterrupted
+			} else {
+				err = e.playAudioInternal(taskCtx, task.AudioData, task.Volume)
+			}
 			task.Done <- err
 			close(task.Done)
 
@@ -122,7 +137,7 @@ func (e *AudioEngine) processQueue() {
 }
 
 // playAudioInternal 内部音频播放实现
-func (e *AudioEngine) playAudioInternal(audioData []byte, volume int) error {
+func (e *AudioEngine) playAudioInternal(ctx context.Context, audioData []byte, volume int) error {
 	if len(audioData) == 0 {
 		return fmt.Errorf("audio data is empty")
 	}
@@ -132,15 +147,15 @@ func (e *AudioEngine) playAudioInternal(audioData []byte, volume int) error {
 	decoder, err := mp3.NewDecoder(reader)
 	if err != nil {
 		// 如果不是 MP3 格式，尝试作为 PCM 数据处理
-		return e.playPCMData(audioData, volume)
+		return e.playPCMData(ctx, audioData, volume)
 	}
 
 	// 播放 MP3 数据
-	return e.playMP3Data(decoder, volume)
+	return e.playMP3Data(ctx, decoder, volume)
 }
 
 // playMP3Data 播放 MP3 格式音频
-func (e *AudioEngine) playMP3Data(decoder *mp3.Decoder, volume int) error {
+func (e *AudioEngine) playMP3Data(ctx context.Context, decoder *mp3.Decoder, volume int) error {
 	// 获取 MP3 的真实音频参数
 	sampleRate := decoder.SampleRate()
 	channelCount := 2 // MP3 通常是立体声，但我们可以根据需要调整
@@ -173,10 +188,19 @@ func (e *AudioEngine) playMP3Data(decoder *mp3.Decoder, volume int) error {
 	player.Play()
 
 	// 等待播放完成
+	return waitPlayer(ctx, player)
+}
+
+// waitPlayer 等待播放完成，上下文取消时立即停止
+func waitPlayer(ctx context.Context, player *oto.Player) error {
 	for player.IsPlaying() {
-		time.Sleep(10 * time.Millisecond)
+		select {
+		case <-ctx.Done():
+			player.Pause()
+			return ErrPlaybackInterrupted
+		case <-time.After(10 * time.Millisecond):
+		}
 	}
-
 	return nil
 }
 
@@ -212,7 +236,7 @@ func (e *AudioEngine) detectPCMFormat(audioData []byte) (sampleRate int, channel
 }
 
 // playPCMData 播放 PCM 格式音频数据
-func (e *AudioEngine) playPCMData(audioData []byte, volume int) error {
+func (e *AudioEngine) playPCMData(ctx context.Context, audioData []byte, volume int) error {
 	// 检查数据长度，确保至少有足够的数据
 	if len(audioData) < 4 {
 		return fmt.Errorf("音频数据太短，至少需要4字节")
@@ -251,11 +275,7 @@ func (e *AudioEngine) playPCMData(audioData []byte, volume int) error {
 	player.Play()
 
 	// 等待播放完成
-	for player.IsPlaying() {
-		time.Sleep(10 * time.Millisecond)
-	}
-
-	return nil
+	return waitPlayer(ctx, player)
 }
 
 // PlayAudio 播放音频的公共接口
@@ -343,6 +363,33 @@ func PlayAudioWithCompletion(audioData []byte) (<-chan struct{}, error) {
 	}
 }
 
+// PlayAudioContext 播放音频并等待完成，ctx 取消时立即停止播放，还在排队时不再播放
+// 音量使用 WithVolume 指定的音量，没有指定时使用 user.json 中的音量
+// 播放被取消时返回 ErrPlaybackInterrupted
+func PlayAudioContext(ctx context.Context, audioData []byte) error {
+	if len(audioData) == 0 {
+		return fmt.Errorf("audio data cannot be empty")
+	}
+
+	engine := getInstance()
+	task := &AudioTask{
+		AudioData: audioData,
+		Volume:    volumeFrom(ctx),
+		Done:      make(chan error, 1),
+		Ctx:       ctx,
+	}
+
+	select {
+	case engine.taskQueue <- task:
+	case <-ctx.Done():
+		return ErrPlaybackInterrupted
+	case <-time.After(60 * time.Second):
+		return fmt.Errorf("audio playback request timeout")
+	}
+	// 取消后引擎会立即停止播放并返回，这里始终等待引擎的结果，避免与下一段音频重叠
+	return <-task.Done
+}
+
 // PlayAudioWithFormat 播放音频的扩展接口，允许指定音频格式
 // audioData: 音频数据
 // volume: 音量 (1-100)
@@ -0,0 +1,70 @@
+package voice
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/CoffeeSwt/bilibili-tts-chat/config"
+)
+
+// Sink 音频输出，多直播间时每个直播间可以使用不同的输出
+type Sink interface {
+	// Play 输出一段音频，返回时音频已播放完成或写入完成
+	Play(ctx context.Context, audioData []byte) error
+}
+
+// volumeKey 上下文中播报音量的键
+type volumeKey struct{}
+
+// WithVolume 在上下文中指定播报音量（1到100），多直播间时每个直播间可以使用不同的音量
+// 只对在声卡上播放的输出有效，写入文件的音频保持原样
+func WithVolume(ctx context.Context, volume int) context.Context {
+	return context.WithValue(ctx, volumeKey{}, volume)
+}
+
+// volumeFrom 返回上下文中指定的音量，没有指定时使用 user.json 中的音量
+func volumeFrom(ctx context.Context) int {
+	if v, ok := ctx.Value(volumeKey{}).(int); ok && v > 0 {
+		return v
+	}
+	return config.GetVolume()
+}
+
+// DeviceSink 通过音频引擎在默认声卡上播放
+type DeviceSink struct{}
+
+// Play 播放音频并等待完成，ctx 取消时立即停止播放
+func (DeviceSink) Play(ctx context.Context, audioData []byte) error {
+	return PlayAudioContext(ctx, audioData)
+}
+
+// FileSink 把音频写入目录中的mp3文件，可交给推流软件等外部程序播放
+type FileSink struct {
+	Dir     string // 输出目录
+	counter atomic.Int64
+}
+
+// NewFileSink 创建文件输出，目录不存在时自动创建
+func NewFileSink(dir string) (*FileSink, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建音频输出目录失败: %v", err)
+	}
+	return &FileSink{Dir: dir}, nil
+}
+
+// Play 把音频写入新文件，文件名包含时间和序号，按文件名排序即为播放顺序
+func (s *FileSink) Play(ctx context.Context, audioData []byte) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("音频输出被取消")
+	}
+
+	name := fmt.Sprintf("%s_%04d.mp3", time.Now().Format("20060102_150405"), s.counter.Add(1))
+	if err := os.WriteFile(filepath.Join(s.Dir, name), audioData, 0644); err != nil {
+		return fmt.Errorf("写入音频文件失败: %v", err)
+	}
+	return nil
+}
@@ -145,6 +145,14 @@ func (a *App) SaveConfig(cfg config.UserConfig) error {
 	return config.SaveUserConfig(cfg)
 }
 
+// SendDanmaku 发送一条手动弹幕，与真实弹幕走相同的播报流程
+func (a *App) SendDanmaku(uname, msg string) error {
+	if a.appManager == nil {
+		return fmt.Errorf("应用未启动")
+	}
+	return a.appManager.SendDanmaku(uname, msg)
+}
+
 // RestartApp 重启应用逻辑（停止旧实例并启动新实例）
 func (a *App) RestartApp() error {
 	logger.Info("前端触发应用重启...")
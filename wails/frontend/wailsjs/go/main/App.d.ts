@@ -9,3 +9,5 @@ export function Greet(arg1:string):Promise<string>;
 export function RestartApp():Promise<void>;
 
 export function SaveConfig(arg1:config.UserConfig):Promise<void>;
+
+export function SendDanmaku(arg1:string,arg2:string):Promise<void>;
@@ -17,3 +17,7 @@ export function RestartApp() {
 export function SaveConfig(arg1) {
   return window['go']['main']['App']['SaveConfig'](arg1);
 }
+
+export function SendDanmaku(arg1, arg2) {
+  return window['go']['main']['App']['SendDanmaku'](arg1, arg2);
+}
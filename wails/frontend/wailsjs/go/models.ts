@@ -11,6 +11,26 @@ export namespace config {
 	    assistant_memory_size: number;
 	    use_llm_replay: boolean;
 	    first_start: boolean;
+	    ws_max_reconnects: number;
+	    ws_reconnect_base_delay: number;
+	    ws_reconnect_max_delay: number;
+	    app_heartbeat_max_failures: number;
+	    record_events: boolean;
+	    dedup_window: number;
+	    dedup_capacity: number;
+	    webhook_addr: string;
+	    webhook_token: string;
+	    tail_file: string;
+	    gift_combo_timeout: number;
+	    like_window: number;
+	    like_min_count: number;
+	    welcome_cooldown: number;
+	    welcome_max_per_minute: number;
+	    welcome_back_days: number;
+	    emoji_mode: string;
+	    emoji_merge_window: number;
+	    emoji_names: Record<string, string>;
+	    voice_preview_cooldown: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new UserConfig(source);
@@ -28,6 +48,26 @@ export namespace config {
 	        this.assistant_memory_size = source["assistant_memory_size"];
 	        this.use_llm_replay = source["use_llm_replay"];
 	        this.first_start = source["first_start"];
+	        this.ws_max_reconnects = source["ws_max_reconnects"];
+	        this.ws_reconnect_base_delay = source["ws_reconnect_base_delay"];
+	        this.ws_reconnect_max_delay = source["ws_reconnect_max_delay"];
+	        this.app_heartbeat_max_failures = source["app_heartbeat_max_failures"];
+	        this.record_events = source["record_events"];
+	        this.dedup_window = source["dedup_window"];
+	        this.dedup_capacity = source["dedup_capacity"];
+	        this.webhook_addr = source["webhook_addr"];
+	        this.webhook_token = source["webhook_token"];
+	        this.tail_file = source["tail_file"];
+	        this.gift_combo_timeout = source["gift_combo_timeout"];
+	        this.like_window = source["like_window"];
+	        this.like_min_count = source["like_min_count"];
+	        this.welcome_cooldown = source["welcome_cooldown"];
+	        this.welcome_max_per_minute = source["welcome_max_per_minute"];
+	        this.welcome_back_days = source["welcome_back_days"];
+	        this.emoji_mode = source["emoji_mode"];
+	        this.emoji_merge_window = source["emoji_merge_window"];
+	        this.emoji_names = source["emoji_names"];
+	        this.voice_preview_cooldown = source["voice_preview_cooldown"];
 	    }
 	}
 